@@ -5,8 +5,11 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
 	"github.com/vmware-tanzu/dependency-labeler/pkg/deplab"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
 
 	"github.com/spf13/cobra"
 )
@@ -14,6 +17,9 @@ import (
 func init() {
 	inspectCmd.Flags().StringVarP(&inputImageTar, "image-tar", "p", "", "`path` to tarball of input image. Cannot be used with --image flag")
 	inspectCmd.Flags().StringVarP(&inputImage, "image", "i", "", "image which will be inspected by deplab. Cannot be used with --image-tar flag")
+	addEOLFlags(inspectCmd.Flags())
+	inspectCmd.Flags().StringVar(&mergePrecedence, "merge-precedence", string(metadata.PreferCurrent), "`precedence` of the existing label and of the image when they conflict, one of "+strings.Join(metadata.Precedences, ", "))
+	inspectCmd.Flags().StringVar(&sbomReferrer, "sbom-referrer", "", "print the sboms referring to the image in this `target`, either oci:<path> to an OCI layout or a repository, instead of the label")
 
 	rootCmd.AddCommand(inspectCmd)
 }
@@ -24,7 +30,14 @@ var inspectCmd = &cobra.Command{
 	Long:    `prints the deplab "io.deplab.metadata" label in the config file of an OCI compatible image to stdout.  The label will be printed in json format.`,
 	PreRunE: validateInspectFlags,
 	RunE: func(_ *cobra.Command, _ []string) error {
-		return deplab.RunInspect(inputImage, inputImageTar)
+		return deplab.RunInspect(common.RunParams{
+			InputImage:        inputImage,
+			InputImageTarPath: inputImageTar,
+			SBOMReferrer:      sbomReferrer,
+			EOLDataPath:       eolDataPath,
+			FailOnEOL:         failOnEOL,
+			MergePrecedence:   mergePrecedence,
+		})
 	},
 }
 
@@ -35,5 +48,9 @@ func validateInspectFlags(cmd *cobra.Command, _ []string) error {
 		return fmt.Errorf("ERROR: cannot accept both --image and --image-tar")
 	}
 
+	if !isOneOf(mergePrecedence, metadata.Precedences) {
+		return fmt.Errorf("ERROR: unsupported --merge-precedence %s, supported precedences are: %s", mergePrecedence, strings.Join(metadata.Precedences, ", "))
+	}
+
 	return nil
 }
@@ -7,29 +7,76 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/additionalsources"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
 
+	"github.com/vmware-tanzu/dependency-labeler/pkg/config"
+
 	"github.com/vmware-tanzu/dependency-labeler/pkg/deplab"
 
+	"github.com/vmware-tanzu/dependency-labeler/pkg/git"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/sbom"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/signature"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	additionalSourceFilePaths []string
+	baseImages                []string
+	baseImageCatalogPaths     []string
+	eolDataPath               string
+	failOnEOL                 bool
+	mergePrecedence           string
 	inputImage                string
 	inputImageTar             string
 	outputImageTar            string
 	gitPaths                  []string
+	gitDirty                  string
+	gitRemote                 string
 	metadataFilePath          string
 	dpkgFilePath              string
 	tag                       string
 	additionalSourceUrls      []string
 	ignoreValidationErrors    bool
+	archiveCacheDir           string
+	offline                   bool
+	allowedHosts              []string
+	proxy                     string
+	urlCacheDir               string
+	urlCacheTTL               time.Duration
+	urlRetries                int
+	providers                 []string
+	skipProviders             []string
+	configFilePath            string
+	warningsFilePath          string
+	failOnWarning             []string
+	extendedProvenance        bool
+	provenanceFilePath        string
+	sbomReferrer              string
+	sbomFormat                string
+	labelFormat               string
+	labelPartSize             int
+	signKeyPath               string
+	signTarget                string
+	signer                    string
+	signatureFilePath         string
+	signatureReferrer         string
 )
 
 func init() {
 	rootCmd.Flags().StringArrayVarP(&gitPaths, "git", "g", []string{}, "`path` to a directory under git revision control")
+	rootCmd.Flags().StringVar(&gitRemote, "git-remote", "", "`name` of the git remote to record. Defaults to origin if present, otherwise the first remote by name")
+	rootCmd.Flags().StringVar(&gitDirty, "git-dirty", git.DirtyAllow, "`policy` for git repositories with uncommitted changes, one of "+strings.Join(git.DirtyPolicies, ", "))
 	rootCmd.Flags().StringVarP(&inputImage, "image", "i", "", "image which will be analysed by deplab. Cannot be used with --image-tar flag")
 	rootCmd.Flags().StringVarP(&inputImageTar, "image-tar", "p", "", "`path` to tarball of input image. Cannot be used with --image flag")
 	rootCmd.Flags().StringVarP(&outputImageTar, "output-tar", "o", "", "`path` to write a tarball of the image to")
@@ -38,7 +85,29 @@ func init() {
 	rootCmd.Flags().StringVarP(&tag, "tag", "t", "", "tags the output image")
 	rootCmd.Flags().StringArrayVarP(&additionalSourceUrls, "additional-source-url", "u", []string{}, "`url` to the source of an added dependency")
 	rootCmd.Flags().StringArrayVarP(&additionalSourceFilePaths, "additional-sources-file", "a", []string{}, "`path` to file describing additional sources")
+	rootCmd.Flags().StringArrayVar(&baseImages, "base-image", []string{}, "`image` which may be the base of the input image, either a reference or the path to a tarball")
+	rootCmd.Flags().StringArrayVar(&baseImageCatalogPaths, "base-image-catalog", []string{}, "`path` to a file listing the layers of images which may be the base of the input image")
 	rootCmd.Flags().BoolVar(&ignoreValidationErrors, "ignore-validation-errors", false, "Set flag to ignore validation errors")
+	rootCmd.Flags().StringVar(&archiveCacheDir, "archive-cache", "", "download additional source archives into this `directory` to verify their digests, or compute them when none was given")
+	rootCmd.Flags().BoolVar(&offline, "offline", false, "do not check that additional source urls are reachable, only their syntax, extension and host")
+	addURLValidationFlags(rootCmd.Flags())
+	rootCmd.Flags().StringSliceVar(&providers, "providers", []string{}, "comma separated `names` of the providers to run, in order. Defaults to all providers")
+	rootCmd.Flags().StringSliceVar(&skipProviders, "skip-providers", []string{}, "comma separated `names` of the providers not to run")
+	rootCmd.Flags().StringVar(&warningsFilePath, "warnings-file", "", "write warnings in json format to a file at this `path`")
+	rootCmd.Flags().StringSliceVar(&failOnWarning, "fail-on-warning", []string{}, "comma separated warning `codes` which make deplab fail, or \""+deplab.FailOnAnyWarning+"\" to fail on any warning")
+	addEOLFlags(rootCmd.Flags())
+	rootCmd.Flags().BoolVar(&extendedProvenance, "extended-provenance", false, "record how the metadata was produced in the deplab provenance entry")
+	rootCmd.Flags().StringVar(&provenanceFilePath, "provenance-file", "", "write an in-toto SLSA provenance statement to a file at this `path`")
+	rootCmd.Flags().StringVar(&sbomReferrer, "sbom-referrer", "", "attach the metadata to the input image as a referrer artifact in this `target`, either oci:<path> to an OCI layout or a repository")
+	rootCmd.Flags().StringVar(&sbomFormat, "sbom-format", sbom.DeplabFormat, "`format` of the sbom attached with --sbom-referrer, one of "+strings.Join(sbom.Formats, ", "))
+	rootCmd.Flags().StringVar(&labelFormat, "label-format", metadata.JSONLabelFormat, "`format` of the metadata label, one of "+strings.Join(metadata.LabelFormats, ", "))
+	rootCmd.Flags().IntVar(&labelPartSize, "label-part-size", metadata.DefaultLabelPartSize, "maximum `size` in bytes of each label with --label-format split")
+	rootCmd.Flags().StringVar(&signKeyPath, "sign-key", "", "`path` to a PEM encoded ecdsa or ed25519 private key to sign with")
+	rootCmd.Flags().StringVar(&signTarget, "sign-target", signature.ImageTarget, "`target` whose digest is signed, one of "+strings.Join(signature.Targets, ", "))
+	rootCmd.Flags().StringVar(&signer, "signer", "", "`identity` of the signer recorded in the signature")
+	rootCmd.Flags().StringVar(&signatureFilePath, "signature-file", "", "write the signature to a file at this `path`")
+	rootCmd.Flags().StringVar(&signatureReferrer, "signature-referrer", "", "attach the signature to the labeled image as a referrer artifact in this `target`, either oci:<path> to an OCI layout or a repository")
+	rootCmd.Flags().StringVarP(&configFilePath, "config", "c", "", "`path` to a deplab config file. Defaults to "+config.DefaultConfigFileName+" in the current directory, if present")
 }
 
 var rootCmd = &cobra.Command{
@@ -61,13 +130,68 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("ERROR: cannot accept both --image and --image-tar")
 	}
 
-	if !isFlagSet(cmd, "metadata-file") && !isFlagSet(cmd, "dpkg-file") && !isFlagSet(cmd, "output-tar") {
-		return fmt.Errorf("ERROR: requires one of --metadata-file, --dpkg-file, or --output-tar")
+	if !isFlagSet(cmd, "metadata-file") && !isFlagSet(cmd, "dpkg-file") && !isFlagSet(cmd, "output-tar") && !isFlagSet(cmd, "sbom-referrer") {
+		return fmt.Errorf("ERROR: requires one of --metadata-file, --dpkg-file, --output-tar, or --sbom-referrer")
+	}
+
+	if !isOneOf(gitDirty, git.DirtyPolicies) {
+		return fmt.Errorf("ERROR: unsupported --git-dirty %s, supported policies are: %s", gitDirty, strings.Join(git.DirtyPolicies, ", "))
+	}
+
+	if !isOneOf(sbomFormat, sbom.Formats) {
+		return fmt.Errorf("ERROR: unsupported --sbom-format %s, supported formats are: %s", sbomFormat, strings.Join(sbom.Formats, ", "))
+	}
+
+	if !isOneOf(labelFormat, metadata.LabelFormats) {
+		return fmt.Errorf("ERROR: unsupported --label-format %s, supported formats are: %s", labelFormat, strings.Join(metadata.LabelFormats, ", "))
+	}
+
+	if labelFormat == metadata.ReferenceLabelFormat && (!isFlagSet(cmd, "sbom-referrer") || sbomFormat != sbom.DeplabFormat) {
+		return fmt.Errorf("ERROR: --label-format %s requires --sbom-referrer with --sbom-format %s", metadata.ReferenceLabelFormat, sbom.DeplabFormat)
+	}
+
+	if labelPartSize <= 0 {
+		return fmt.Errorf("ERROR: --label-part-size must be positive")
+	}
+
+	if isFlagSet(cmd, "sign-key") && !isFlagSet(cmd, "signature-file") && !isFlagSet(cmd, "signature-referrer") {
+		return fmt.Errorf("ERROR: --sign-key requires one of --signature-file or --signature-referrer")
+	} else if !isFlagSet(cmd, "sign-key") && (isFlagSet(cmd, "signature-file") || isFlagSet(cmd, "signature-referrer")) {
+		return fmt.Errorf("ERROR: --signature-file and --signature-referrer require --sign-key")
+	}
+
+	if !isOneOf(signTarget, signature.Targets) {
+		return fmt.Errorf("ERROR: unsupported --sign-target %s, supported targets are: %s", signTarget, strings.Join(signature.Targets, ", "))
 	}
 
 	return nil
 }
 
+// addURLValidationFlags adds the flags configuring how additional source urls
+// are validated.
+func addURLValidationFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&allowedHosts, "allowed-hosts", []string{}, "comma separated `hosts` additional source urls must be on, e.g. *.example.com. Defaults to any host")
+	flags.StringVar(&proxy, "proxy", "", "`url` of the proxy to reach additional source urls through. Defaults to the proxy from the environment")
+	flags.StringVar(&urlCacheDir, "url-cache", "", "cache reachable additional source urls in this `directory`")
+	flags.DurationVar(&urlCacheTTL, "url-cache-ttl", additionalsources.DefaultURLCacheTTL, "`duration` for which a reachable url is cached")
+	flags.IntVar(&urlRetries, "url-retries", additionalsources.DefaultURLRetries, "`number` of times to retry reaching an additional source url, with an exponential backoff")
+}
+
+// addEOLFlags adds the flags configuring the end of life of the base.
+func addEOLFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&eolDataPath, "eol-data", "", "`path` to a file of distribution lifecycle data, taking precedence over the bundled data")
+	flags.BoolVar(&failOnEOL, "fail-on-eol", false, "fail when the base of the image is past its end of life")
+}
+
+func isOneOf(value string, supported []string) bool {
+	for _, s := range supported {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
 func isFlagSet(cmd *cobra.Command, flagName string) bool {
 	flagSet := cmd.Flags()
 	flag, err := flagSet.GetString(flagName)
@@ -78,23 +202,87 @@ func isFlagSet(cmd *cobra.Command, flagName string) bool {
 	return flag != ""
 }
 
-func run(_ *cobra.Command, _ []string) {
-	err := deplab.Run(
-		common.RunParams{
-			InputImageTarPath:         inputImageTar,
-			InputImage:                inputImage,
-			GitPaths:                  gitPaths,
-			Tag:                       tag,
-			OutputImageTar:            outputImageTar,
-			MetadataFilePath:          metadataFilePath,
-			DpkgFilePath:              dpkgFilePath,
-			AdditionalSourceUrls:      additionalSourceUrls,
-			AdditionalSourceFilePaths: additionalSourceFilePaths,
-			IgnoreValidationErrors:    ignoreValidationErrors,
-		})
+func run(cmd *cobra.Command, _ []string) {
+	params := common.RunParams{
+		InputImageTarPath:         inputImageTar,
+		InputImage:                inputImage,
+		GitPaths:                  gitPaths,
+		GitDirty:                  gitDirty,
+		GitRemote:                 gitRemote,
+		Tag:                       tag,
+		OutputImageTar:            outputImageTar,
+		MetadataFilePath:          metadataFilePath,
+		DpkgFilePath:              dpkgFilePath,
+		AdditionalSourceUrls:      additionalSourceUrls,
+		AdditionalSourceFilePaths: additionalSourceFilePaths,
+		BaseImages:                baseImages,
+		BaseImageCatalogPaths:     baseImageCatalogPaths,
+		EOLDataPath:               eolDataPath,
+		FailOnEOL:                 failOnEOL,
+		IgnoreValidationErrors:    ignoreValidationErrors,
+		ArchiveCacheDir:           archiveCacheDir,
+		Offline:                   offline,
+		AllowedHosts:              allowedHosts,
+		Proxy:                     proxy,
+		URLCacheDir:               urlCacheDir,
+		URLCacheTTL:               urlCacheTTL,
+		URLRetries:                urlRetries,
+		Providers:                 providers,
+		SkipProviders:             skipProviders,
+		WarningsFilePath:          warningsFilePath,
+		FailOnWarning:             failOnWarning,
+		Flags:                     setFlags(cmd),
+		ExtendedProvenance:        extendedProvenance,
+		ProvenanceFilePath:        provenanceFilePath,
+		SBOMReferrer:              sbomReferrer,
+		SBOMFormat:                sbomFormat,
+		LabelFormat:               labelFormat,
+		LabelPartSize:             labelPartSize,
+		SignKeyPath:               signKeyPath,
+		SignTarget:                signTarget,
+		Signer:                    signer,
+		SignatureFilePath:         signatureFilePath,
+		SignatureReferrer:         signatureReferrer,
+	}
+
+	params, err := applyConfigFile(params)
 	if err != nil {
 		log.Fatalf("deplab failed to run. %s\n", err)
 	}
+
+	err = deplab.Run(params)
+	if err != nil {
+		log.Fatalf("deplab failed to run. %s\n", err)
+	}
+}
+
+func setFlags(cmd *cobra.Command) map[string][]string {
+	flags := map[string][]string{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			flags[flag.Name] = sliceValue.GetSlice()
+		} else {
+			flags[flag.Name] = []string{flag.Value.String()}
+		}
+	})
+	return flags
+}
+
+func applyConfigFile(params common.RunParams) (common.RunParams, error) {
+	path := configFilePath
+	if path == "" {
+		if _, err := os.Stat(config.DefaultConfigFileName); err != nil {
+			return params, nil
+		}
+		path = config.DefaultConfigFileName
+	}
+
+	deplabConfig, err := config.ParseConfigFile(path)
+	if err != nil {
+		return params, err
+	}
+
+	return deplabConfig.Apply(params), nil
 }
 
 func main() {
@@ -0,0 +1,37 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/deplab"
+
+	"github.com/spf13/cobra"
+)
+
+var checkURLs bool
+
+func init() {
+	validateSourcesCmd.Flags().BoolVar(&checkURLs, "check-urls", false, "also check that the archive urls are reachable")
+	addURLValidationFlags(validateSourcesCmd.Flags())
+
+	rootCmd.AddCommand(validateSourcesCmd)
+}
+
+var validateSourcesCmd = &cobra.Command{
+	Use:   "validate-sources <file>...",
+	Short: "validates additional sources files",
+	Long:  `validates additional sources files against their schema without needing an image. Version 2 files are validated strictly, reporting unknown, missing and mistyped fields with their line and column.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		return deplab.RunValidateSources(args, common.RunParams{
+			Offline:      !checkURLs,
+			AllowedHosts: allowedHosts,
+			Proxy:        proxy,
+			URLCacheDir:  urlCacheDir,
+			URLCacheTTL:  urlCacheTTL,
+			URLRetries:   urlRetries,
+		})
+	},
+}
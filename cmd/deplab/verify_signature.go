@@ -0,0 +1,52 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/deplab"
+
+	"github.com/spf13/cobra"
+)
+
+var publicKeyPath string
+
+func init() {
+	verifySignatureCmd.Flags().StringVarP(&inputImageTar, "image-tar", "p", "", "`path` to tarball of the labeled image. Cannot be used with --image flag")
+	verifySignatureCmd.Flags().StringVarP(&inputImage, "image", "i", "", "labeled image whose signature will be verified. Cannot be used with --image-tar flag")
+	verifySignatureCmd.Flags().StringVar(&publicKeyPath, "public-key", "", "`path` to the PEM encoded public key to verify the signature with")
+	verifySignatureCmd.Flags().StringVar(&signatureFilePath, "signature-file", "", "`path` to a signature file written by deplab")
+	verifySignatureCmd.Flags().StringVar(&signatureReferrer, "signature-referrer", "", "verify the signatures referring to the image in this `target`, either oci:<path> to an OCI layout or a repository")
+
+	rootCmd.AddCommand(verifySignatureCmd)
+}
+
+var verifySignatureCmd = &cobra.Command{
+	Use:     "verify-signature",
+	Short:   "verifies the signature of a labeled image",
+	Long:    `verifies a signature written by deplab against a public key, and reports who signed which digest of the labeled image or of its "io.deplab.metadata" label.`,
+	PreRunE: validateVerifySignatureFlags,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return deplab.RunVerifySignature(inputImage, inputImageTar, publicKeyPath, signatureFilePath, signatureReferrer)
+	},
+}
+
+func validateVerifySignatureFlags(cmd *cobra.Command, _ []string) error {
+	if !isFlagSet(cmd, "image") && !isFlagSet(cmd, "image-tar") {
+		return fmt.Errorf("ERROR: requires one of --image or --image-tar")
+	} else if isFlagSet(cmd, "image") && isFlagSet(cmd, "image-tar") {
+		return fmt.Errorf("ERROR: cannot accept both --image and --image-tar")
+	}
+
+	if !isFlagSet(cmd, "public-key") {
+		return fmt.Errorf("ERROR: requires --public-key")
+	}
+
+	if !isFlagSet(cmd, "signature-file") && !isFlagSet(cmd, "signature-referrer") {
+		return fmt.Errorf("ERROR: requires one of --signature-file or --signature-referrer")
+	}
+
+	return nil
+}
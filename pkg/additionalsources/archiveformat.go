@@ -0,0 +1,192 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ArchiveFormat recognizes a kind of source archive by the extension of its
+// url or file name, the content type given by the server, or the bytes found
+// at an offset of its content. Any field can be left empty.
+type ArchiveFormat struct {
+	Extension   string `yaml:"extension"`
+	ContentType string `yaml:"content_type"`
+	Magic       string `yaml:"magic"`
+	MagicOffset int    `yaml:"magic_offset"`
+}
+
+type ArchiveFormats []ArchiveFormat
+
+// https://en.wikipedia.org/wiki/Tar_(computing)#Suffixes_for_compressed_files
+var archiveExtensions = []string{
+	"7z",
+	"tar.bz2",
+	"tar.gz",
+	"tar.lz",
+	"tar.lzma",
+	"tar.lzo",
+	"tar.xz",
+	"tar.Z",
+	"tar.zst",
+	"taz",
+	"taZ",
+	"tb2",
+	"tbz",
+	"tbz2",
+	"tgz",
+	"tlz",
+	"tpz",
+	"txz",
+	"tZ",
+	"tz2",
+	"tzst",
+	"zip",
+	"tar",
+	"crate",
+	"gem",
+	"whl",
+	"jar",
+	"deb",
+}
+
+var archiveContentTypes = []string{
+	"application/gzip",
+	"application/java-archive",
+	"application/vnd.debian.binary-package",
+	"application/x-7z-compressed",
+	"application/x-bzip2",
+	"application/x-compress",
+	"application/x-gtar",
+	"application/x-gzip",
+	"application/x-lzip",
+	"application/x-lzma",
+	"application/x-tar",
+	"application/x-xz",
+	"application/x-zip-compressed",
+	"application/zip",
+	"application/zstd",
+}
+
+var archiveMagics = []ArchiveFormat{
+	{Magic: "1f8b"},                         // gzip
+	{Magic: "1f9d"},                         // compress
+	{Magic: "425a68"},                       // bzip2
+	{Magic: "fd377a585a00"},                 // xz
+	{Magic: "28b52ffd"},                     // zstd
+	{Magic: "4c5a4950"},                     // lzip
+	{Magic: "5d0000"},                       // lzma
+	{Magic: "504b0304"},                     // zip, jar, whl
+	{Magic: "504b0506"},                     // empty zip
+	{Magic: "377abcaf271c"},                 // 7z
+	{Magic: "213c617263683e0a"},             // ar, deb
+	{Magic: "7573746172", MagicOffset: 257}, // tar, gem
+}
+
+// DefaultArchiveFormats are the formats recognized without registering any.
+func DefaultArchiveFormats() ArchiveFormats {
+	var formats ArchiveFormats
+	for _, extension := range archiveExtensions {
+		formats = append(formats, ArchiveFormat{Extension: extension})
+	}
+	for _, contentType := range archiveContentTypes {
+		formats = append(formats, ArchiveFormat{ContentType: contentType})
+	}
+	return append(formats, archiveMagics...)
+}
+
+// Validate checks that the format can recognize archives.
+func (f ArchiveFormat) Validate() error {
+	if f.Extension == "" && f.ContentType == "" && f.Magic == "" {
+		return fmt.Errorf("archive format requires one of extension, content_type or magic")
+	}
+	if _, err := hex.DecodeString(f.Magic); err != nil {
+		return fmt.Errorf("archive format magic %s is not hex encoded", f.Magic)
+	}
+	if f.MagicOffset < 0 {
+		return fmt.Errorf("archive format magic_offset %d is negative", f.MagicOffset)
+	}
+	return nil
+}
+
+// MatchExtension reports whether the url or file name ends with one of the
+// extensions, ignoring any fragment of the url.
+func (formats ArchiveFormats) MatchExtension(name string) bool {
+	for _, f := range formats {
+		if f.Extension == "" {
+			continue
+		}
+		extension := "." + strings.TrimPrefix(f.Extension, ".")
+		if strings.HasSuffix(name, extension) || strings.Contains(name, extension+"#") {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchResponse reports whether the content type of the response is one of
+// the content types, or the file name of its content disposition has one of
+// the extensions.
+func (formats ArchiveFormats) MatchResponse(header http.Header) bool {
+	if contentType, _, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil {
+		for _, f := range formats {
+			if f.ContentType != "" && strings.EqualFold(f.ContentType, contentType) {
+				return true
+			}
+		}
+	}
+
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return formats.MatchExtension(params["filename"])
+	}
+
+	return false
+}
+
+// MatchContent reports whether the content starts with one of the magics.
+func (formats ArchiveFormats) MatchContent(r io.Reader) (bool, error) {
+	maxLength := 0
+	for _, f := range formats {
+		if length := f.MagicOffset + len(f.Magic)/2; f.Magic != "" && length > maxLength {
+			maxLength = length
+		}
+	}
+
+	content := make([]byte, maxLength)
+	n, err := io.ReadFull(r, content)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	content = content[:n]
+
+	for _, f := range formats {
+		magic, err := hex.DecodeString(f.Magic)
+		if err != nil || len(magic) == 0 {
+			continue
+		}
+		if f.MagicOffset+len(magic) <= len(content) && bytes.Equal(content[f.MagicOffset:f.MagicOffset+len(magic)], magic) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MatchFile reports whether the content of the file starts with one of the
+// magics.
+func (formats ArchiveFormats) MatchFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	return formats.MatchContent(f)
+}
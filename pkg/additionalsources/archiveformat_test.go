@@ -0,0 +1,121 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/additionalsources"
+)
+
+var _ = Describe("ArchiveFormats", func() {
+	formats := DefaultArchiveFormats()
+
+	DescribeTable("MatchExtension", func(name string, expected bool) {
+		Expect(formats.MatchExtension(name)).To(Equal(expected))
+	},
+		Entry("matches a compressed tarball", "https://example.com/source.tar.gz", true),
+		Entry("matches an uncompressed tarball", "https://example.com/source.tar", true),
+		Entry("matches a crate", "https://example.com/serde-1.0.0.crate", true),
+		Entry("matches a gem", "https://example.com/rake-13.0.1.gem", true),
+		Entry("matches a wheel", "https://example.com/six-1.15.0-py2.py3-none-any.whl", true),
+		Entry("matches a jar", "https://example.com/commons-io-2.8.0-sources.jar", true),
+		Entry("matches a deb", "https://example.com/ca-certificates_20180409.deb", true),
+		Entry("ignores the fragment", "https://example.com/source.tgz#sha256=abc", true),
+		Entry("does not match a tag", "https://github.com/example/project/archive/refs/tags/v1.2", false),
+		Entry("does not match an unknown extension", "https://example.com/source.txt", false),
+	)
+
+	It("has no duplicate default formats", func() {
+		seen := map[ArchiveFormat]bool{}
+		for _, format := range formats {
+			Expect(seen).ToNot(HaveKey(format))
+			seen[format] = true
+		}
+	})
+
+	It("matches registered extensions with or without a leading dot", func() {
+		Expect(ArchiveFormats{{Extension: ".src.bundle"}}.MatchExtension("source.src.bundle")).To(BeTrue())
+		Expect(ArchiveFormats{{Extension: "src.bundle"}}.MatchExtension("source.src.bundle")).To(BeTrue())
+	})
+
+	DescribeTable("MatchResponse", func(contentType, contentDisposition string, expected bool) {
+		header := http.Header{}
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Disposition", contentDisposition)
+		Expect(formats.MatchResponse(header)).To(Equal(expected))
+	},
+		Entry("matches an archive content type", "application/gzip", "", true),
+		Entry("matches a content type with parameters", "application/zip; charset=binary", "", true),
+		Entry("matches the file name of the content disposition", "application/octet-stream", `attachment; filename="source.tar.gz"`, true),
+		Entry("does not match a generic content type alone", "application/octet-stream", "", false),
+		Entry("does not match html", "text/html", `inline; filename="index.html"`, false),
+	)
+
+	DescribeTable("MatchContent", func(content []byte, expected bool) {
+		ok, err := formats.MatchContent(bytes.NewReader(content))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(Equal(expected))
+	},
+		Entry("matches gzip", []byte{0x1f, 0x8b, 0x08, 0x00}, true),
+		Entry("matches zip", []byte("PK\x03\x04rest"), true),
+		Entry("matches ar", []byte("!<arch>\ndebian-binary"), true),
+		Entry("matches tar at its offset", append(make([]byte, 257), []byte("ustar\x0000")...), true),
+		Entry("does not match text", []byte("test"), false),
+		Entry("does not match empty content", []byte{}, false),
+	)
+
+	It("matches registered magics at their offset", func() {
+		ok, err := ArchiveFormats{{Magic: "42554e44", MagicOffset: 2}}.MatchContent(bytes.NewReader([]byte("..BUND")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+	})
+
+	DescribeTable("Validate", func(format ArchiveFormat, expectedError string) {
+		err := format.Validate()
+		if expectedError == "" {
+			Expect(err).ToNot(HaveOccurred())
+		} else {
+			Expect(err).To(MatchError(expectedError))
+		}
+	},
+		Entry("accepts an extension", ArchiveFormat{Extension: "bundle"}, ""),
+		Entry("requires a way to recognize archives", ArchiveFormat{MagicOffset: 2}, "archive format requires one of extension, content_type or magic"),
+		Entry("rejects a magic which is not hex", ArchiveFormat{Magic: "BUND"}, "archive format magic BUND is not hex encoded"),
+		Entry("rejects a negative offset", ArchiveFormat{Magic: "42", MagicOffset: -1}, "archive format magic_offset -1 is negative"),
+	)
+
+	Describe("ParseAdditionalSourcesFile", func() {
+		var sourcesFilePath string
+
+		BeforeEach(func() {
+			f, err := ioutil.TempFile("", "sources-file-")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.WriteString(`version: 2
+formats:
+  - extension: .src.bundle
+  - magic: BUND
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+			sourcesFilePath = f.Name()
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(sourcesFilePath)).To(Succeed())
+		})
+
+		It("keeps the valid formats without their leading dot and reports the others", func() {
+			additionalSources, _, err := ParseAdditionalSourcesFile(sourcesFilePath)
+			Expect(err).To(MatchError("archive format magic BUND is not hex encoded"))
+			Expect(additionalSources.Formats).To(Equal([]ArchiveFormat{{Extension: "src.bundle"}}))
+		})
+	})
+})
@@ -4,9 +4,11 @@
 package additionalsources
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
@@ -18,82 +20,247 @@ import (
 
 type HTTPHeadFn func(url string) (resp *http.Response, err error)
 
-func ArchiveUrlProvider(_ image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
+type HTTPGetFn func(url string) (resp *http.Response, err error)
+
+// archiveAttributes are the keys accepted in the fragment of an
+// --additional-source-url, e.g. https://example.com/source.tgz#sha256=...&license=MIT
+var archiveAttributes = []string{"sha256", "sha512", "license", "name", "version"}
+
+var (
+	sha256Pattern = regexp.MustCompile("^[0-9a-f]{64}$")
+	sha512Pattern = regexp.MustCompile("^[0-9a-f]{128}$")
+)
+
+func ArchiveUrlProvider(_ image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	var archives []AdditionalSourceArchive
 	for _, archiveURL := range params.AdditionalSourceUrls {
-		dependency, err := BuildArchiveDependencyMetadata(archiveURL)
+		archives = append(archives, ParseArchiveURL(archiveURL))
+	}
+
+	return archivesProvider(archives, nil, params, md)
+}
+
+func AdditionalSourcesProvider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	var warnings []common.Warning
+	var archives []AdditionalSourceArchive
+	var formats ArchiveFormats
+	var files []AdditionalSourceFile
+	for _, additionalSourcesFile := range params.AdditionalSourceFilePaths {
+		additionalSources, vcsFromAdditionalSourcesFile, err := ParseAdditionalSourcesFile(additionalSourcesFile)
 		if err != nil {
-			return metadata.Metadata{}, err
-		}
-		ok, message := IsValidURL(archiveURL, http.Head)
-		if !ok {
-			errMsg := fmt.Sprintf("failed to validate additional source url: %s", message)
+			errMsg := fmt.Sprintf("could not parse additional sources file: %s, %s", additionalSourcesFile, err)
 			if params.IgnoreValidationErrors {
-				log.Printf("warning: %s", errMsg) //TODO return warning?
+				warnings = append(warnings, common.Warning{
+					Code:    common.InvalidSourcesFileWarning,
+					Message: errMsg,
+				})
 			} else {
-				return metadata.Metadata{}, fmt.Errorf("error: %s", errMsg)
+				return metadata.Metadata{}, nil, fmt.Errorf("error: %s", errMsg)
 			}
 		}
+		archives = append(archives, additionalSources.Archives...)
+		formats = append(formats, additionalSources.Formats...)
+		files = append(files, additionalSources.Files...)
+		md.Dependencies = append(md.Dependencies, vcsFromAdditionalSourcesFile...)
+	}
+
+	for _, file := range files {
+		dependency, err := BuildFileDependencyMetadata(dli, file)
+		if err != nil {
+			errMsg := fmt.Sprintf("could not find additional source files: %s", err)
+			if params.IgnoreValidationErrors {
+				warnings = append(warnings, common.Warning{
+					Code:           common.MissingSourceFileWarning,
+					Message:        errMsg,
+					DependencyType: metadata.FileSourceType,
+				})
+				continue
+			}
+			return metadata.Metadata{}, nil, fmt.Errorf("error: %s", errMsg)
+		}
 		md.Dependencies = append(md.Dependencies, dependency)
 	}
 
-	return md, nil
+	// archives of sources files are always validated, regardless of
+	// --ignore-validation-errors
+	archiveParams := params
+	archiveParams.IgnoreValidationErrors = false
+	md, archiveWarnings, err := archivesProvider(archives, formats, archiveParams, md)
+
+	return md, append(warnings, archiveWarnings...), err
 }
 
-func AdditionalSourcesProvider(_ image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
-	var archiveUrls []string
-	for _, additionalSourcesFile := range params.AdditionalSourceFilePaths {
-		archiveUrlsFromAdditionalSourcesFile, gitVcsFromAdditionalSourcesFile, err := ParseAdditionalSourcesFile(additionalSourcesFile)
-		if err != nil {
-			errMsg := fmt.Sprintf("could not parse additional sources file: %s, %s", additionalSourcesFile, err)
-			if params.IgnoreValidationErrors {
-				log.Printf("warning: %s", errMsg) //TODO want to return warnings, and deal with logging in caller
+// archivesProvider validates the archives and adds them to the metadata,
+// recognizing the registered formats on top of the default ones. With a cache
+// directory the archives are downloaded, their digests verified, or computed
+// when none was given, and their content checked to be an archive. Otherwise
+// the urls are only checked to be reachable.
+func archivesProvider(archives []AdditionalSourceArchive, formats ArchiveFormats, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	validator, err := NewURLValidator(params)
+	if err != nil {
+		return metadata.Metadata{}, nil, err
+	}
+	validator.Formats = append(validator.Formats, formats...)
+
+	errMsgs := make([]string, len(archives))
+	codes := make([]string, len(archives))
+
+	var reachableURLs []string
+	var reachableIndexes []int
+	for i, archive := range archives {
+		codes[i] = common.InvalidSourceURLWarning
+
+		if err := ValidateArchive(archive); err != nil {
+			errMsgs[i] = fmt.Sprintf("failed to validate additional source url: %s", err)
+		} else if err := validator.ValidateOffline(archive.Url); err != nil {
+			errMsgs[i] = fmt.Sprintf("failed to validate additional source url: %s", err)
+		} else if params.ArchiveCacheDir != "" {
+			fetched, err := FetchArchive(archive, params.ArchiveCacheDir, archiveGetFn(validator))
+			if err != nil {
+				errMsgs[i] = fmt.Sprintf("failed to fetch additional source url: %s", err)
+				if errors.Is(err, ErrDigestMismatch) {
+					codes[i] = common.ArchiveDigestWarning
+				}
+			} else if err := checkCachedArchive(fetched.Url, params.ArchiveCacheDir, validator.Formats); err != nil {
+				errMsgs[i] = fmt.Sprintf("failed to validate additional source url: %s", err)
 			} else {
-				return metadata.Metadata{}, fmt.Errorf("error: %s", errMsg)
+				archives[i] = fetched
 			}
+		} else {
+			reachableURLs = append(reachableURLs, archive.Url)
+			reachableIndexes = append(reachableIndexes, i)
 		}
-		archiveUrls = append(archiveUrls, archiveUrlsFromAdditionalSourcesFile...)
-		md.Dependencies = append(md.Dependencies, gitVcsFromAdditionalSourcesFile...)
 	}
-	return ArchiveUrlProvider(nil, common.RunParams{AdditionalSourceUrls: archiveUrls}, md)
+
+	for j, err := range validator.Validate(reachableURLs) {
+		if err != nil {
+			errMsgs[reachableIndexes[j]] = fmt.Sprintf("failed to validate additional source url: %s", err)
+		}
+	}
+
+	var warnings []common.Warning
+	for i, archive := range archives {
+		if errMsgs[i] != "" {
+			if !params.IgnoreValidationErrors {
+				return metadata.Metadata{}, nil, fmt.Errorf("error: %s", errMsgs[i])
+			}
+			warnings = append(warnings, common.Warning{
+				Code:           codes[i],
+				Message:        errMsgs[i],
+				DependencyType: metadata.ArchiveType,
+			})
+		}
+
+		dependency, err := BuildArchiveDependencyMetadata(archive)
+		if err != nil {
+			return metadata.Metadata{}, nil, err
+		}
+		md.Dependencies = append(md.Dependencies, dependency)
+	}
+
+	return md, warnings, nil
 }
 
-func BuildArchiveDependencyMetadata(archiveUrl string) (metadata.Dependency, error) {
+// checkCachedArchive recognizes a downloaded archive by the extension of its
+// url or, failing that, by its content.
+func checkCachedArchive(archiveURL, cacheDir string, formats ArchiveFormats) error {
+	if formats.MatchExtension(archiveURL) {
+		return nil
+	}
+
+	ok, err := formats.MatchFile(cachePath(archiveURL, cacheDir))
+	if err != nil {
+		return fmt.Errorf("could not read cached archive: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("unsupported archive type for url %s: its content is not a known archive format", archiveURL)
+	}
+	return nil
+}
+
+// archiveGetFn downloads archives through the proxy of the validator, and
+// refuses to download anything when offline, so that only archives already in
+// the cache can be used.
+func archiveGetFn(validator URLValidator) HTTPGetFn {
+	if validator.Offline {
+		return func(archiveURL string) (*http.Response, error) {
+			return nil, fmt.Errorf("%s is not in the archive cache and cannot be downloaded offline", archiveURL)
+		}
+	}
+
+	return httpClient(validator.Proxy, 0).Get
+}
+
+// ParseArchiveURL reads the attributes of an archive from the fragment of its
+// url. Fragments which are not made only of known attributes are kept as part
+// of the url.
+func ParseArchiveURL(archiveURL string) AdditionalSourceArchive {
+	i := strings.LastIndex(archiveURL, "#")
+	if i < 0 {
+		return AdditionalSourceArchive{Url: archiveURL}
+	}
+
+	values, err := url.ParseQuery(archiveURL[i+1:])
+	if err != nil || len(values) == 0 {
+		return AdditionalSourceArchive{Url: archiveURL}
+	}
+	for key, value := range values {
+		if !isOneOf(key, archiveAttributes) || len(value) != 1 {
+			return AdditionalSourceArchive{Url: archiveURL}
+		}
+	}
+
+	return AdditionalSourceArchive{
+		Url:     archiveURL[:i],
+		Sha256:  strings.ToLower(values.Get("sha256")),
+		Sha512:  strings.ToLower(values.Get("sha512")),
+		License: values.Get("license"),
+		Name:    values.Get("name"),
+		Version: values.Get("version"),
+	}
+}
+
+// ValidateArchive checks the format of the digests of the archive. Its url is
+// checked by the URLValidator.
+func ValidateArchive(archive AdditionalSourceArchive) error {
+	var errorMessages []string
+	if archive.Sha256 != "" && !sha256Pattern.MatchString(archive.Sha256) {
+		errorMessages = append(errorMessages, fmt.Sprintf("invalid sha256 digest %s for url %s", archive.Sha256, archive.Url))
+	}
+	if archive.Sha512 != "" && !sha512Pattern.MatchString(archive.Sha512) {
+		errorMessages = append(errorMessages, fmt.Sprintf("invalid sha512 digest %s for url %s", archive.Sha512, archive.Url))
+	}
+
+	if len(errorMessages) != 0 {
+		return fmt.Errorf(strings.Join(errorMessages, ", "))
+	}
+	return nil
+}
+
+func BuildArchiveDependencyMetadata(archive AdditionalSourceArchive) (metadata.Dependency, error) {
 	return metadata.Dependency{
 		Type: "package",
 		Source: metadata.Source{
 			Type: metadata.ArchiveType,
 			Metadata: metadata.ArchiveSourceMetadata{
-				URL: archiveUrl,
+				URL:     archive.Url,
+				Name:    archive.Name,
+				Version: archive.Version,
+				License: archive.License,
+				Sha256:  archive.Sha256,
+				Sha512:  archive.Sha512,
 			},
 		},
 	}, nil
 }
 
-// https://en.wikipedia.org/wiki/Tar_(computing)#Suffixes_for_compressed_files
-var SupportedExtensions = []string{
-	"7z",
-	"tar.bz2",
-	"tar.gz",
-	"tar.lz",
-	"tar.lzma",
-	"tar.lzo",
-	"tar.xz",
-	"tar.Z",
-	"tar.zst",
-	"taz",
-	"taZ",
-	"tb2",
-	"tbz",
-	"tbz2",
-	"tgz",
-	"tlz",
-	"tpz",
-	"txz",
-	"tZ",
-	"tz2",
-	"tzst",
-	"tar.bz2",
-	"zip",
+func isOneOf(value string, supported []string) bool {
+	for _, s := range supported {
+		if value == s {
+			return true
+		}
+	}
+	return false
 }
 
 func ValidateURLs(additionalSourceUrls []string, fn HTTPHeadFn) error {
@@ -110,26 +277,8 @@ func ValidateURLs(additionalSourceUrls []string, fn HTTPHeadFn) error {
 }
 
 func IsValidURL(additionalSourceUrl string, fn HTTPHeadFn) (bool, string) {
-	if !isValidExtension(additionalSourceUrl) {
-		return false, fmt.Sprintf("unsupported extension for url %s", additionalSourceUrl)
-	}
-
-	if resp, err := fn(additionalSourceUrl); err != nil {
-		return false, fmt.Sprintf("invalid url: %s", err)
-	} else {
-		if resp.StatusCode > 299 {
-			return false, fmt.Sprintf("got status code %d when trying to reach %s (expected 2xx)", resp.StatusCode, additionalSourceUrl)
-		}
+	if err := (URLValidator{Head: fn}).Validate([]string{additionalSourceUrl})[0]; err != nil {
+		return false, err.Error()
 	}
 	return true, ""
 }
-
-func isValidExtension(sourceUrl string) bool {
-	for _, extension := range SupportedExtensions {
-		if strings.HasSuffix(sourceUrl, "."+extension) ||
-			strings.Contains(sourceUrl, "."+extension+"#") {
-			return true
-		}
-	}
-	return false
-}
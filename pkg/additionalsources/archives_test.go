@@ -4,8 +4,12 @@
 package additionalsources_test
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -121,8 +125,120 @@ var _ = Describe("additionalsources", func() {
 			)...,
 		)
 	})
+
+	Describe("ParseArchiveURL", func() {
+		It("reads the attributes of the archive from the fragment of the url", func() {
+			archive := ParseArchiveURL("https://example.com/source.tgz#sha256=9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08&license=Apache-2.0&name=source&version=1.0.0")
+			Expect(archive).To(Equal(AdditionalSourceArchive{
+				Url:     "https://example.com/source.tgz",
+				Sha256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+				License: "Apache-2.0",
+				Name:    "source",
+				Version: "1.0.0",
+			}))
+		})
+
+		DescribeTable("keeps fragments which are not attributes as part of the url", func(archiveURL string) {
+			Expect(ParseArchiveURL(archiveURL)).To(Equal(AdditionalSourceArchive{Url: archiveURL}))
+		},
+			Entry("without a fragment", "https://example.com/source.tgz"),
+			Entry("with a plain fragment", "https://example.com/source.zip#with-an-ignored-fragment"),
+			Entry("with an unknown attribute", "https://example.com/source.zip#sha256=abc&md5=def"),
+			Entry("with a repeated attribute", "https://example.com/source.zip#name=a&name=b"),
+		)
+	})
+
+	Describe("ValidateArchive", func() {
+		It("accepts an archive with valid digests", func() {
+			Expect(ValidateArchive(AdditionalSourceArchive{
+				Url:    "https://example.com/source.tgz",
+				Sha256: sha256Digest,
+				Sha512: sha512Digest,
+			})).To(Succeed())
+		})
+
+		It("rejects malformed digests", func() {
+			err := ValidateArchive(AdditionalSourceArchive{
+				Url:    "https://example.com/source.tgz",
+				Sha256: "abc",
+				Sha512: sha256Digest,
+			})
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring("invalid sha256 digest abc"),
+				ContainSubstring("invalid sha512 digest "+sha256Digest),
+			)))
+		})
+	})
+
+	Describe("FetchArchive", func() {
+		var (
+			cacheDir  string
+			downloads int
+			get       HTTPGetFn
+		)
+
+		BeforeEach(func() {
+			var err error
+			cacheDir, err = ioutil.TempDir("", "archive-cache-")
+			Expect(err).ToNot(HaveOccurred())
+
+			downloads = 0
+			get = func(_ string) (*http.Response, error) {
+				downloads++
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("test"))}, nil
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(cacheDir)).To(Succeed())
+		})
+
+		It("computes the sha256 digest when none was given", func() {
+			archive, err := FetchArchive(AdditionalSourceArchive{Url: "https://example.com/source.tgz"}, cacheDir, get)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(archive.Sha256).To(Equal(sha256Digest))
+			Expect(archive.Sha512).To(BeEmpty())
+		})
+
+		It("verifies the given digests", func() {
+			archive, err := FetchArchive(AdditionalSourceArchive{Url: "https://example.com/source.tgz", Sha512: sha512Digest}, cacheDir, get)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(archive.Sha512).To(Equal(sha512Digest))
+			Expect(archive.Sha256).To(BeEmpty())
+		})
+
+		It("returns a digest mismatch error when the archive does not match", func() {
+			otherDigest := strings.Repeat("0", 64)
+			_, err := FetchArchive(AdditionalSourceArchive{Url: "https://example.com/source.tgz", Sha256: otherDigest}, cacheDir, get)
+			Expect(errors.Is(err, ErrDigestMismatch)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("expected sha256 " + otherDigest + ", got " + sha256Digest)))
+		})
+
+		It("reuses a cached archive which matches the digest", func() {
+			archive := AdditionalSourceArchive{Url: "https://example.com/source.tgz", Sha256: sha256Digest}
+			_, err := FetchArchive(archive, cacheDir, get)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = FetchArchive(archive, cacheDir, get)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(downloads).To(Equal(1))
+		})
+
+		It("returns an error when the archive cannot be downloaded", func() {
+			_, err := FetchArchive(AdditionalSourceArchive{Url: "https://example.com/source.tgz"}, cacheDir, func(_ string) (*http.Response, error) {
+				return &http.Response{StatusCode: 404, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+			})
+			Expect(err).To(MatchError(ContainSubstring("got status code 404")))
+		})
+	})
 })
 
+// digests of the content "test"
+const (
+	sha256Digest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	sha512Digest = "ee26b0dd4af7e749aa1a8ee3c10ae9923f618980772e473f8819a5d4940e0db27ac185f8a0e1d5f84f88bc887fd67b143732c304cc5fa9ad8e6f57f50028a8ff"
+)
+
 func generateEntries(extensions ...string) []TableEntry {
 	var entries []TableEntry
 
@@ -0,0 +1,122 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// FetchArchive makes sure the archive is in the cache directory and returns
+// it with its digests verified. When no digest was given, the sha256 digest of
+// the archive is computed. An archive already in the cache is only downloaded
+// again if it does not match the given digests.
+func FetchArchive(archive AdditionalSourceArchive, cacheDir string, get HTTPGetFn) (AdditionalSourceArchive, error) {
+	archivePath := cachePath(archive.Url, cacheDir)
+
+	if _, err := os.Stat(archivePath); err == nil {
+		if fetched, err := verifyArchive(archive, archivePath); err == nil {
+			return fetched, nil
+		}
+	}
+
+	if err := downloadArchive(archive.Url, archivePath, get); err != nil {
+		return AdditionalSourceArchive{}, err
+	}
+
+	return verifyArchive(archive, archivePath)
+}
+
+// cachePath keeps each url in its own directory, so that archives with the
+// same file name do not overwrite each other.
+func cachePath(archiveURL, cacheDir string) string {
+	name := "archive"
+	if u, err := url.Parse(archiveURL); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+		name = path.Base(u.Path)
+	}
+
+	urlDigest := sha256.Sum256([]byte(archiveURL))
+	return filepath.Join(cacheDir, hex.EncodeToString(urlDigest[:]), name)
+}
+
+func downloadArchive(archiveURL, archivePath string, get HTTPGetFn) error {
+	err := os.MkdirAll(filepath.Dir(archivePath), 0755)
+	if err != nil {
+		return fmt.Errorf("could not create cache directory: %w", err)
+	}
+
+	resp, err := get(archiveURL)
+	if err != nil {
+		return fmt.Errorf("could not download %s: %w", archiveURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("got status code %d when trying to download %s (expected 2xx)", resp.StatusCode, archiveURL)
+	}
+
+	// the archive is renamed into place once complete, so that an interrupted
+	// download is never mistaken for a cached archive
+	tmpFile, err := ioutil.TempFile(filepath.Dir(archivePath), ".download-")
+	if err != nil {
+		return fmt.Errorf("could not create file in cache directory: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = io.Copy(tmpFile, resp.Body)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not download %s: %w", archiveURL, err)
+	}
+
+	err = os.Rename(tmpFile.Name(), archivePath)
+	if err != nil {
+		return fmt.Errorf("could not move %s into the cache directory: %w", archiveURL, err)
+	}
+
+	return nil
+}
+
+func verifyArchive(archive AdditionalSourceArchive, archivePath string) (AdditionalSourceArchive, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return AdditionalSourceArchive{}, fmt.Errorf("could not open cached archive: %w", err)
+	}
+	defer f.Close()
+
+	sha256Hash, sha512Hash := sha256.New(), sha512.New()
+	_, err = io.Copy(io.MultiWriter(sha256Hash, sha512Hash), f)
+	if err != nil {
+		return AdditionalSourceArchive{}, fmt.Errorf("could not read cached archive: %w", err)
+	}
+
+	sha256Digest := hex.EncodeToString(sha256Hash.Sum(nil))
+	sha512Digest := hex.EncodeToString(sha512Hash.Sum(nil))
+
+	if archive.Sha256 != "" && archive.Sha256 != sha256Digest {
+		return AdditionalSourceArchive{}, fmt.Errorf("%w for %s: expected sha256 %s, got %s", ErrDigestMismatch, archive.Url, archive.Sha256, sha256Digest)
+	}
+	if archive.Sha512 != "" && archive.Sha512 != sha512Digest {
+		return AdditionalSourceArchive{}, fmt.Errorf("%w for %s: expected sha512 %s, got %s", ErrDigestMismatch, archive.Url, archive.Sha512, sha512Digest)
+	}
+
+	if archive.Sha256 == "" && archive.Sha512 == "" {
+		archive.Sha256 = sha256Digest
+	}
+
+	return archive, nil
+}
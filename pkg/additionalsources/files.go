@@ -0,0 +1,165 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// linkImage is implemented by the images which can tell symbolic links apart,
+// so that links are not followed outside of the image.
+type linkImage interface {
+	LinkTarget(path string) (string, bool, error)
+}
+
+// BuildFileDependencyMetadata hashes the files of the image matching the path
+// of the entry. Matching directories are included with all their files.
+// Symbolic links are recorded with their target, which may be outside of the
+// image or not exist, rather than hashed.
+func BuildFileDependencyMetadata(dli image.Image, file AdditionalSourceFile) (metadata.Dependency, error) {
+	paths, err := matchFiles(dli, file.Path)
+	if err != nil {
+		return metadata.Dependency{}, err
+	}
+	if len(paths) == 0 {
+		return metadata.Dependency{}, fmt.Errorf("no files in the image match %s", file.Path)
+	}
+
+	sourceFiles := []metadata.SourceFile{}
+	links, _ := dli.(linkImage)
+	for _, filePath := range paths {
+		if links != nil {
+			target, isLink, err := links.LinkTarget(filePath)
+			if err != nil {
+				return metadata.Dependency{}, fmt.Errorf("could not read %s: %w", filePath, err)
+			}
+			if isLink {
+				sourceFiles = append(sourceFiles, metadata.SourceFile{
+					Path:       filePath,
+					Size:       int64(len(target)),
+					LinkTarget: target,
+				})
+				continue
+			}
+		}
+
+		content, err := dli.GetFileContent(filePath)
+		if err != nil {
+			return metadata.Dependency{}, fmt.Errorf("could not read %s: %w", filePath, err)
+		}
+
+		digest := sha256.Sum256([]byte(content))
+		sourceFiles = append(sourceFiles, metadata.SourceFile{
+			Path:   filePath,
+			Size:   int64(len(content)),
+			Sha256: hex.EncodeToString(digest[:]),
+		})
+	}
+
+	return metadata.Dependency{
+		Type: "package",
+		Source: metadata.Source{
+			Type: metadata.FileSourceType,
+			Metadata: metadata.FileSourceMetadata{
+				Path:    file.Path,
+				Origin:  file.Origin,
+				License: file.License,
+				Files:   sourceFiles,
+			},
+		},
+	}, nil
+}
+
+// ValidateFilePattern checks the syntax of the path of a files entry.
+func ValidateFilePattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid path %s: %w", pattern, err)
+	}
+	return nil
+}
+
+// matchFiles expands the pattern one path element at a time, listing the
+// directories of the image, and returns the sorted paths of the matching
+// files.
+func matchFiles(dli image.Image, pattern string) ([]string, error) {
+	if err := ValidateFilePattern(pattern); err != nil {
+		return nil, err
+	}
+
+	elements := strings.Split(strings.Trim(path.Clean("/"+pattern), "/"), "/")
+	if elements[0] == "" {
+		return walkFiles(dli, "/")
+	}
+
+	dirs := []string{"/"}
+	var matchedFiles []string
+	for i, element := range elements {
+		last := i == len(elements)-1
+
+		var matchedDirs []string
+		for _, dir := range dirs {
+			files, subDirs, err := image.ListDir(dli, dir)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, name := range subDirs {
+				if ok, _ := path.Match(element, name); ok {
+					matchedDirs = append(matchedDirs, path.Join(dir, name))
+				}
+			}
+			if !last {
+				continue
+			}
+			for _, name := range files {
+				if ok, _ := path.Match(element, name); ok {
+					matchedFiles = append(matchedFiles, path.Join(dir, name))
+				}
+			}
+		}
+		dirs = matchedDirs
+	}
+
+	for _, dir := range dirs {
+		files, err := walkFiles(dli, dir)
+		if err != nil {
+			return nil, err
+		}
+		matchedFiles = append(matchedFiles, files...)
+	}
+
+	sort.Strings(matchedFiles)
+	return matchedFiles, nil
+}
+
+// walkFiles returns the paths of all the files below the directory.
+func walkFiles(dli image.Image, dir string) ([]string, error) {
+	files, subDirs, err := image.ListDir(dli, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, name := range files {
+		paths = append(paths, path.Join(dir, name))
+	}
+	for _, name := range subDirs {
+		subDirPaths, err := walkFiles(dli, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, subDirPaths...)
+	}
+
+	return paths, nil
+}
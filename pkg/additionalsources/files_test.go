@@ -0,0 +1,218 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/additionalsources"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// MockImage is an image whose rootfs holds the given files and symbolic
+// links, by path.
+type MockImage struct {
+	files map[string]string
+	links map[string]string
+}
+
+func (m MockImage) GetConfig() (*v1.ConfigFile, error) {
+	panic("implement me")
+}
+
+func (m MockImage) GetFileContent(filePath string) (string, error) {
+	content, ok := m.files[filePath]
+	if !ok {
+		return "", fmt.Errorf("could not find file in rootFS: %s", filePath)
+	}
+	return content, nil
+}
+
+func (m MockImage) GetDirFileNames(dir string, includeDir bool) ([]string, error) {
+	found := dir == "/"
+	names := map[string]bool{}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	var paths []string
+	for filePath := range m.files {
+		paths = append(paths, filePath)
+	}
+	for linkPath := range m.links {
+		paths = append(paths, linkPath)
+	}
+	for _, filePath := range paths {
+		if !strings.HasPrefix(filePath, prefix) {
+			continue
+		}
+		found = true
+		rest := strings.TrimPrefix(filePath, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			if includeDir {
+				names[rest[:i]] = true
+			}
+			continue
+		}
+		names[rest] = true
+	}
+	if !found {
+		return nil, fmt.Errorf("could not find directory in rootFS: %s", dir)
+	}
+
+	var fileNames []string
+	for name := range names {
+		fileNames = append(fileNames, name)
+	}
+	sort.Strings(fileNames)
+	return fileNames, nil
+}
+
+func (m MockImage) LinkTarget(filePath string) (string, bool, error) {
+	target, ok := m.links[filePath]
+	return target, ok, nil
+}
+
+func (m MockImage) GetDirContents(string) ([]string, error) {
+	panic("implement me")
+}
+
+func (m MockImage) AbsolutePath(string) (string, error) {
+	panic("implement me")
+}
+
+func (m MockImage) ExportWithMetadata(metadata.Metadata, string, string) error {
+	panic("implement me")
+}
+
+var _ = Describe("files", func() {
+	dli := MockImage{files: map[string]string{
+		"/opt/vendor/lib/a.js":      "test",
+		"/opt/vendor/lib/b.js":      "",
+		"/opt/vendor/lib/nested/c":  "test",
+		"/opt/vendor/README.md":     "readme",
+		"/usr/share/other/file.txt": "other",
+	}}
+
+	Describe("BuildFileDependencyMetadata", func() {
+		It("records the files matching a glob with their size and sha256 digest", func() {
+			dependency, err := BuildFileDependencyMetadata(dli, AdditionalSourceFile{
+				Path:    "/opt/vendor/lib/*.js",
+				Origin:  "https://example.com/lib",
+				License: "MIT",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(dependency).To(Equal(metadata.Dependency{
+				Type: "package",
+				Source: metadata.Source{
+					Type: metadata.FileSourceType,
+					Metadata: metadata.FileSourceMetadata{
+						Path:    "/opt/vendor/lib/*.js",
+						Origin:  "https://example.com/lib",
+						License: "MIT",
+						Files: []metadata.SourceFile{
+							{Path: "/opt/vendor/lib/a.js", Size: 4, Sha256: sha256Digest},
+							{Path: "/opt/vendor/lib/b.js", Size: 0, Sha256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+						},
+					},
+				},
+			}))
+		})
+
+		It("includes all the files below a matching directory", func() {
+			dependency, err := BuildFileDependencyMetadata(dli, AdditionalSourceFile{Path: "opt/*"})
+			Expect(err).ToNot(HaveOccurred())
+
+			var paths []string
+			for _, f := range dependency.Source.Metadata.(metadata.FileSourceMetadata).Files {
+				paths = append(paths, f.Path)
+			}
+			Expect(paths).To(Equal([]string{
+				"/opt/vendor/README.md",
+				"/opt/vendor/lib/a.js",
+				"/opt/vendor/lib/b.js",
+				"/opt/vendor/lib/nested/c",
+			}))
+		})
+
+		It("records symbolic links with their target without following them", func() {
+			dli := MockImage{
+				files: map[string]string{"/opt/vendor/lib/a.js": "test"},
+				links: map[string]string{
+					"/opt/vendor/lib/passwd":  "/etc/passwd",
+					"/opt/vendor/lib/missing": "../gone.js",
+				},
+			}
+
+			dependency, err := BuildFileDependencyMetadata(dli, AdditionalSourceFile{Path: "/opt/vendor/lib"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(dependency.Source.Metadata.(metadata.FileSourceMetadata).Files).To(Equal([]metadata.SourceFile{
+				{Path: "/opt/vendor/lib/a.js", Size: 4, Sha256: sha256Digest},
+				{Path: "/opt/vendor/lib/missing", Size: 10, LinkTarget: "../gone.js"},
+				{Path: "/opt/vendor/lib/passwd", Size: 11, LinkTarget: "/etc/passwd"},
+			}))
+		})
+
+		It("returns an error when no file matches", func() {
+			_, err := BuildFileDependencyMetadata(dli, AdditionalSourceFile{Path: "/opt/vendor/*.go"})
+			Expect(err).To(MatchError("no files in the image match /opt/vendor/*.go"))
+		})
+
+		It("returns an error for a malformed glob", func() {
+			_, err := BuildFileDependencyMetadata(dli, AdditionalSourceFile{Path: "/opt/[vendor"})
+			Expect(err).To(MatchError(ContainSubstring("invalid path /opt/[vendor")))
+		})
+	})
+
+	Describe("AdditionalSourcesProvider", func() {
+		var sourcesFilePath string
+
+		BeforeEach(func() {
+			f, err := ioutil.TempFile("", "sources-file-")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.WriteString(`version: 2
+files:
+  - path: /opt/vendor/lib/nested
+    license: Apache-2.0
+  - path: /opt/missing
+`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+			sourcesFilePath = f.Name()
+		})
+
+		AfterEach(func() {
+			Expect(os.Remove(sourcesFilePath)).To(Succeed())
+		})
+
+		It("returns an error when files are missing from the image", func() {
+			_, _, err := AdditionalSourcesProvider(dli, common.RunParams{AdditionalSourceFilePaths: []string{sourcesFilePath}}, metadata.Metadata{})
+			Expect(err).To(MatchError(ContainSubstring("no files in the image match /opt/missing")))
+		})
+
+		It("reports missing files as warnings when ignoring validation errors", func() {
+			md, warnings, err := AdditionalSourcesProvider(dli, common.RunParams{
+				AdditionalSourceFilePaths: []string{sourcesFilePath},
+				IgnoreValidationErrors:    true,
+			}, metadata.Metadata{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(warnings).To(HaveLen(1))
+			Expect(warnings[0].Code).To(Equal(common.MissingSourceFileWarning))
+
+			Expect(md.Dependencies).To(HaveLen(1))
+			fileSourceMetadata := md.Dependencies[0].Source.Metadata.(metadata.FileSourceMetadata)
+			Expect(fileSourceMetadata.License).To(Equal("Apache-2.0"))
+			Expect(path.Base(fileSourceMetadata.Files[0].Path)).To(Equal("c"))
+		})
+	})
+})
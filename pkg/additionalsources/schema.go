@@ -0,0 +1,148 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LegacyVersion is assumed for files without a version. They are decoded
+	// leniently, ignoring unknown fields.
+	LegacyVersion = 1
+	// CurrentVersion files are decoded strictly.
+	CurrentVersion = 2
+)
+
+// ParseAdditionalSources decodes the content of an additional sources file,
+// either yaml or json. Unknown, missing and mistyped fields of a version 2
+// file are reported with their line and column.
+func ParseAdditionalSources(content []byte) (AdditionalSources, error) {
+	var document yaml.Node
+	err := yaml.Unmarshal(content, &document)
+	if err != nil {
+		return AdditionalSources{}, err
+	}
+
+	if len(document.Content) == 0 {
+		return AdditionalSources{}, fmt.Errorf("the file is empty")
+	}
+	root := document.Content[0]
+
+	version, err := schemaVersion(root)
+	if err != nil {
+		return AdditionalSources{}, err
+	}
+
+	switch version {
+	case LegacyVersion:
+	case CurrentVersion:
+		if errorMessages := checkNode(root, reflect.TypeOf(AdditionalSources{}), "additional sources"); len(errorMessages) != 0 {
+			return AdditionalSources{}, fmt.Errorf(strings.Join(errorMessages, ", "))
+		}
+	default:
+		return AdditionalSources{}, fmt.Errorf("unsupported version %d, supported versions are %d and %d", version, LegacyVersion, CurrentVersion)
+	}
+
+	var additionalSources AdditionalSources
+	err = root.Decode(&additionalSources)
+	if err != nil {
+		return AdditionalSources{}, err
+	}
+	additionalSources.Version = version
+
+	return additionalSources, nil
+}
+
+func schemaVersion(root *yaml.Node) (int, error) {
+	if root.Kind != yaml.MappingNode {
+		return 0, positionError(root, "expected a mapping for additional sources")
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "version" {
+			continue
+		}
+
+		var version int
+		if err := root.Content[i+1].Decode(&version); err != nil {
+			return 0, positionError(root.Content[i+1], "version must be an integer")
+		}
+		return version, nil
+	}
+
+	return LegacyVersion, nil
+}
+
+// checkNode compares the node with the yaml tags of the type it decodes into.
+func checkNode(node *yaml.Node, t reflect.Type, context string) []string {
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return []string{positionError(node, "expected a mapping for %s", context).Error()}
+		}
+
+		var errorMessages []string
+		present := map[string]bool{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			present[key.Value] = true
+
+			field, ok := fieldByTag(t, key.Value)
+			if !ok {
+				errorMessages = append(errorMessages, positionError(key, "unknown field %q in %s", key.Value, context).Error())
+				continue
+			}
+			errorMessages = append(errorMessages, checkNode(value, field.Type, key.Value)...)
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			name := yamlName(t.Field(i))
+			if t.Field(i).Tag.Get("required") == "true" && !present[name] {
+				errorMessages = append(errorMessages, positionError(node, "missing field %q in %s", name, context).Error())
+			}
+		}
+		return errorMessages
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return []string{positionError(node, "expected a list for %s", context).Error()}
+		}
+
+		var errorMessages []string
+		for _, item := range node.Content {
+			errorMessages = append(errorMessages, checkNode(item, t.Elem(), context+" entry")...)
+		}
+		return errorMessages
+	default:
+		if node.Kind != yaml.ScalarNode {
+			return []string{positionError(node, "expected a value for %s", context).Error()}
+		}
+
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			return []string{positionError(node, "invalid value %q for %s", node.Value, context).Error()}
+		}
+		return nil
+	}
+}
+
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func yamlName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("yaml"), ",")[0]
+}
+
+func positionError(node *yaml.Node, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d, column %d: %s", node.Line, node.Column, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/additionalsources"
+)
+
+var _ = Describe("ParseAdditionalSources", func() {
+	expected := AdditionalSources{
+		Version:  CurrentVersion,
+		Archives: []AdditionalSourceArchive{{Url: "https://example.com/source.tar.gz"}},
+		Vcs:      []AdditionalSourceVcs{{Protocol: "git", Version: "abc123", Url: "git@github.com:org/repo.git"}},
+	}
+
+	It("parses a version 2 yaml file", func() {
+		additionalSources, err := ParseAdditionalSources([]byte(`version: 2
+archives:
+  - url: https://example.com/source.tar.gz
+vcs:
+  - protocol: git
+    version: abc123
+    url: git@github.com:org/repo.git
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(additionalSources).To(Equal(expected))
+	})
+
+	It("parses the same document as json", func() {
+		additionalSources, err := ParseAdditionalSources([]byte(`{
+  "version": 2,
+  "archives": [{"url": "https://example.com/source.tar.gz"}],
+  "vcs": [{"protocol": "git", "version": "abc123", "url": "git@github.com:org/repo.git"}]
+}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(additionalSources).To(Equal(expected))
+	})
+
+	It("ignores unknown fields of a file without a version", func() {
+		additionalSources, err := ParseAdditionalSources([]byte(`vcs:
+  - protocol: git
+    version: abc123
+    url: git@github.com:org/repo.git
+    refs: v1.0.0
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(additionalSources.Version).To(Equal(LegacyVersion))
+		Expect(additionalSources.Vcs).To(Equal(expected.Vcs))
+	})
+
+	It("reports unknown fields of a version 2 file with their position", func() {
+		_, err := ParseAdditionalSources([]byte(`version: 2
+archives:
+  - url: https://example.com/source.tar.gz
+    checksum: abc
+vcs:
+  - protocol: git
+    version: abc123
+    url: git@github.com:org/repo.git
+    refs: v1.0.0
+`))
+		Expect(err).To(MatchError(SatisfyAll(
+			ContainSubstring(`line 4, column 5: unknown field "checksum" in archives entry`),
+			ContainSubstring(`line 9, column 5: unknown field "refs" in vcs entry`),
+		)))
+	})
+
+	It("reports missing and mistyped fields of a version 2 file with their position", func() {
+		_, err := ParseAdditionalSources([]byte(`version: 2
+archives: https://example.com/source.tar.gz
+vcs:
+  - protocol: git
+    url: git@github.com:org/repo.git
+`))
+		Expect(err).To(MatchError(SatisfyAll(
+			ContainSubstring(`line 2, column 11: expected a list for archives`),
+			ContainSubstring(`line 4, column 5: missing field "version" in vcs entry`),
+		)))
+	})
+
+	It("parses the optional digests, license, name and version of archives", func() {
+		additionalSources, err := ParseAdditionalSources([]byte(`version: 2
+archives:
+  - url: https://example.com/source.tar.gz
+    sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+    license: Apache-2.0
+    name: source
+    version: 1.0.0
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(additionalSources.Archives).To(Equal([]AdditionalSourceArchive{{
+			Url:     "https://example.com/source.tar.gz",
+			Sha256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			License: "Apache-2.0",
+			Name:    "source",
+			Version: "1.0.0",
+		}}))
+	})
+
+	It("parses the archive formats", func() {
+		additionalSources, err := ParseAdditionalSources([]byte(`version: 2
+formats:
+  - extension: src.bundle
+  - content_type: application/x-bundle
+    magic: 42554e44
+    magic_offset: 4
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(additionalSources.Formats).To(Equal([]ArchiveFormat{
+			{Extension: "src.bundle"},
+			{ContentType: "application/x-bundle", Magic: "42554e44", MagicOffset: 4},
+		}))
+	})
+
+	It("rejects unsupported versions", func() {
+		_, err := ParseAdditionalSources([]byte(`version: 3`))
+		Expect(err).To(MatchError(ContainSubstring("unsupported version 3")))
+
+		_, err = ParseAdditionalSources([]byte(`version: two`))
+		Expect(err).To(MatchError(ContainSubstring("line 1, column 10: version must be an integer")))
+	})
+
+	It("rejects an empty file", func() {
+		_, err := ParseAdditionalSources([]byte(``))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -5,53 +5,94 @@ package additionalsources
 
 import (
 	"fmt"
-	"os"
+	"io/ioutil"
 	"strings"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/git"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
-
-	"gopkg.in/yaml.v2"
 )
 
-func ParseAdditionalSourcesFile(additionalSourcesFilePath string) ([]string, []metadata.Dependency, error) {
-	additionalSourcesFileReader, err := os.Open(additionalSourcesFilePath)
+// ParseAdditionalSourcesFile returns the archives and files of an additional
+// sources file, which still have to be checked, and the dependencies of its
+// vcs entries. Files with an invalid path and invalid archive formats are left
+// out.
+func ParseAdditionalSourcesFile(additionalSourcesFilePath string) (AdditionalSources, []metadata.Dependency, error) {
+	content, err := ioutil.ReadFile(additionalSourcesFilePath)
 	if err != nil {
-		return nil, nil, err
+		return AdditionalSources{}, nil, err
 	}
 
-	decoder := yaml.NewDecoder(additionalSourcesFileReader)
-	var additionalSources AdditionalSources
-	err = decoder.Decode(&additionalSources)
+	additionalSources, err := ParseAdditionalSources(content)
 	if err != nil {
-		return nil, nil, err
+		return AdditionalSources{}, nil, err
 	}
 
-	var urls []string
-	for _, archive := range additionalSources.Archives {
-		urls = append(urls, archive.Url)
+	for i, archive := range additionalSources.Archives {
+		additionalSources.Archives[i].Sha256 = strings.ToLower(archive.Sha256)
+		additionalSources.Archives[i].Sha512 = strings.ToLower(archive.Sha512)
 	}
 
-	var gitDependencies []metadata.Dependency
+	var vcsDependencies []metadata.Dependency
 	var errorMessages []string
+
+	var files []AdditionalSourceFile
+	for _, file := range additionalSources.Files {
+		if err := ValidateFilePattern(file.Path); err != nil {
+			errorMessages = append(errorMessages, err.Error())
+			continue
+		}
+		files = append(files, file)
+	}
+	additionalSources.Files = files
+
+	var formats []ArchiveFormat
+	for _, format := range additionalSources.Formats {
+		if err := format.Validate(); err != nil {
+			errorMessages = append(errorMessages, err.Error())
+			continue
+		}
+		format.Extension = strings.TrimPrefix(format.Extension, ".")
+		formats = append(formats, format)
+	}
+	additionalSources.Formats = formats
+
 	for _, vcs := range additionalSources.Vcs {
+		if vcs.Hash != "" && vcs.Protocol != metadata.GoModuleSourceType {
+			errorMessages = append(errorMessages, fmt.Sprintf("vcs %s does not support a hash: %s", vcs.Protocol, vcs.Url))
+		}
+
 		switch vcs.Protocol {
 		case metadata.GitSourceType:
 			if !git.IsValidGitDependency(vcs.Url) {
 				errorMessages = append(errorMessages, fmt.Sprintf("vcs git url in an unsupported format: %s", vcs.Url))
 			}
-			gitDependencies = append(gitDependencies, CreateGitDependency(vcs))
+			vcsDependencies = append(vcsDependencies, CreateGitDependency(vcs))
+		case metadata.HgSourceType:
+			if !IsValidHgDependency(vcs.Url, vcs.Version) {
+				errorMessages = append(errorMessages, fmt.Sprintf("vcs hg url or changeset in an unsupported format: %s %s", vcs.Url, vcs.Version))
+			}
+			vcsDependencies = append(vcsDependencies, CreateHgDependency(vcs))
+		case metadata.SvnSourceType:
+			if !IsValidSvnDependency(vcs.Url, vcs.Version) {
+				errorMessages = append(errorMessages, fmt.Sprintf("vcs svn url or revision in an unsupported format: %s %s", vcs.Url, vcs.Version))
+			}
+			vcsDependencies = append(vcsDependencies, CreateSvnDependency(vcs))
+		case metadata.GoModuleSourceType:
+			if !IsValidGoDependency(vcs.Url, vcs.Version, vcs.Hash) {
+				errorMessages = append(errorMessages, fmt.Sprintf("vcs go module path, version or hash in an unsupported format: %s %s %s", vcs.Url, vcs.Version, vcs.Hash))
+			}
+			vcsDependencies = append(vcsDependencies, CreateGoDependency(vcs))
 		default:
 			errorMessages = append(errorMessages, fmt.Sprintf("unsupported vcs protocol: %s", vcs.Protocol))
 		}
 	}
 
 	if len(errorMessages) != 0 {
-		return urls, gitDependencies, fmt.Errorf(strings.Join(errorMessages, ", "))
+		return additionalSources, vcsDependencies, fmt.Errorf(strings.Join(errorMessages, ", "))
 	}
 
-	return urls, gitDependencies, nil
+	return additionalSources, vcsDependencies, nil
 }
 
 func CreateGitDependency(vcs AdditionalSourceVcs) metadata.Dependency {
@@ -69,3 +110,41 @@ func CreateGitDependency(vcs AdditionalSourceVcs) metadata.Dependency {
 		},
 	}
 }
+
+// ValidateAdditionalSourcesFile checks an additional sources file without
+// adding its sources to any image. Archive urls are only checked to be
+// reachable when the validator is not offline, recognizing the formats
+// registered in the file on top of the ones of the validator.
+func ValidateAdditionalSourcesFile(additionalSourcesFilePath string, validator URLValidator) error {
+	additionalSources, _, err := ParseAdditionalSourcesFile(additionalSourcesFilePath)
+	if err != nil {
+		return err
+	}
+
+	if validator.Formats == nil {
+		validator.Formats = DefaultArchiveFormats()
+	}
+	validator.Formats = append(append(ArchiveFormats{}, validator.Formats...), additionalSources.Formats...)
+
+	var errorMessages []string
+	var urls []string
+	for _, archive := range additionalSources.Archives {
+		if err := ValidateArchive(archive); err != nil {
+			errorMessages = append(errorMessages, err.Error())
+			continue
+		}
+		urls = append(urls, archive.Url)
+	}
+
+	for _, err := range validator.Validate(urls) {
+		if err != nil {
+			errorMessages = append(errorMessages, err.Error())
+		}
+	}
+
+	if len(errorMessages) != 0 {
+		return fmt.Errorf(strings.Join(errorMessages, ", "))
+	}
+
+	return nil
+}
@@ -3,17 +3,40 @@
 
 package additionalsources
 
+// AdditionalSources is the content of an additional sources file. Fields
+// tagged as required must be present from version 2 of the schema.
 type AdditionalSources struct {
-	Archives []AdditionalSourceArchive `yml:"archives"`
-	Vcs      []AdditionalSourceVcs     `yml:"vcs"`
+	Version  int                       `yaml:"version"`
+	Archives []AdditionalSourceArchive `yaml:"archives"`
+	Vcs      []AdditionalSourceVcs     `yaml:"vcs"`
+	Files    []AdditionalSourceFile    `yaml:"files"`
+	Formats  []ArchiveFormat           `yaml:"formats"`
 }
 
+// AdditionalSourceArchive is an archive of sources. The digests, license,
+// name and version are optional.
 type AdditionalSourceArchive struct {
-	Url string `yml:"url"`
+	Url     string `yaml:"url" required:"true"`
+	Sha256  string `yaml:"sha256"`
+	Sha512  string `yaml:"sha512"`
+	License string `yaml:"license"`
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
 }
 
+// AdditionalSourceVcs is a version of a repository. For go entries the url
+// is the module path, and the go.sum hash of the module is required.
 type AdditionalSourceVcs struct {
-	Protocol string `yml:"protocol"`
-	Version  string `yml:"version"`
-	Url      string `yml:"url"`
+	Protocol string `yaml:"protocol" required:"true"`
+	Version  string `yaml:"version" required:"true"`
+	Url      string `yaml:"url" required:"true"`
+	Hash     string `yaml:"hash"`
+}
+
+// AdditionalSourceFile is a path or glob naming files copied into the image,
+// with their declared origin and license.
+type AdditionalSourceFile struct {
+	Path    string `yaml:"path" required:"true"`
+	Origin  string `yaml:"origin"`
+	License string `yaml:"license"`
 }
@@ -0,0 +1,237 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+)
+
+const (
+	DefaultURLRetries  = 2
+	DefaultURLCacheTTL = 24 * time.Hour
+
+	urlTimeout         = 30 * time.Second
+	urlBackoff         = time.Second
+	urlCheckerParallel = 8
+)
+
+// URLValidator checks additional source urls. The offline checks always run,
+// the reachability of the urls is only checked when not offline.
+type URLValidator struct {
+	Formats      ArchiveFormats
+	AllowedHosts []string
+	Offline      bool
+	Proxy        *url.URL
+	Head         HTTPHeadFn
+	Retries      int
+	Backoff      time.Duration
+	CacheDir     string
+	CacheTTL     time.Duration
+}
+
+// NewURLValidator returns a validator configured from the run parameters,
+// going through the given proxy if any, or the proxy from the environment.
+func NewURLValidator(params common.RunParams) (URLValidator, error) {
+	var proxy *url.URL
+	if params.Proxy != "" {
+		var err error
+		proxy, err = url.Parse(params.Proxy)
+		if err != nil {
+			return URLValidator{}, fmt.Errorf("invalid proxy url %s: %w", params.Proxy, err)
+		}
+	}
+
+	return URLValidator{
+		Formats:      DefaultArchiveFormats(),
+		AllowedHosts: params.AllowedHosts,
+		Offline:      params.Offline,
+		Proxy:        proxy,
+		Head:         httpClient(proxy, urlTimeout).Head,
+		Retries:      params.URLRetries,
+		Backoff:      urlBackoff,
+		CacheDir:     params.URLCacheDir,
+		CacheTTL:     params.URLCacheTTL,
+	}, nil
+}
+
+// httpClient returns a client going through the proxy, or the proxy from the
+// environment when nil.
+func httpClient(proxy *url.URL, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// ValidateOffline checks the syntax and host of a url without reaching it.
+func (v URLValidator) ValidateOffline(sourceURL string) error {
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return fmt.Errorf("invalid url %s: %w", sourceURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %s: expected an http or https url", sourceURL)
+	}
+
+	if len(v.AllowedHosts) != 0 && !isAllowedHost(u.Hostname(), v.AllowedHosts) {
+		return fmt.Errorf("host %s of url %s is not one of the allowed hosts: %s", u.Hostname(), sourceURL, strings.Join(v.AllowedHosts, ", "))
+	}
+
+	return nil
+}
+
+// Validate checks each url, reaching the ones which pass the offline checks
+// concurrently, and returns the errors in the order of the urls. Urls without
+// a known extension are recognized as archives from the response, so they
+// are rejected when offline.
+func (v URLValidator) Validate(sourceURLs []string) []error {
+	errs := make([]error, len(sourceURLs))
+	formats := v.Formats
+	if formats == nil {
+		formats = DefaultArchiveFormats()
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, urlCheckerParallel)
+	for i, sourceURL := range sourceURLs {
+		if errs[i] = v.ValidateOffline(sourceURL); errs[i] != nil {
+			continue
+		}
+
+		knownExtension := formats.MatchExtension(sourceURL)
+		if v.Offline {
+			if !knownExtension {
+				errs[i] = fmt.Errorf("unsupported extension for url %s", sourceURL)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, sourceURL string, checkFormat bool) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			errs[i] = v.checkReachable(sourceURL, formats, checkFormat)
+		}(i, sourceURL, !knownExtension)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// checkReachable sends a HEAD request to the url, retrying with an exponential
+// backoff on network errors and server errors, and checks that the response is
+// an archive when checkFormat is set. Valid urls are cached.
+func (v URLValidator) checkReachable(sourceURL string, formats ArchiveFormats, checkFormat bool) error {
+	if v.isCached(sourceURL) {
+		return nil
+	}
+
+	backoff := v.Backoff
+	var err error
+	for attempt := 0; attempt <= v.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		var resp *http.Response
+		resp, err = v.Head(sourceURL)
+		if err != nil {
+			err = fmt.Errorf("invalid url: %s", err)
+			continue
+		}
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+
+		if resp.StatusCode <= 299 {
+			if checkFormat && !formats.MatchResponse(resp.Header) {
+				return fmt.Errorf("unsupported archive type for url %s: neither its extension nor its content type %q is a known archive format", sourceURL, resp.Header.Get("Content-Type"))
+			}
+			v.cache(sourceURL)
+			return nil
+		}
+
+		err = fmt.Errorf("got status code %d when trying to reach %s (expected 2xx)", resp.StatusCode, sourceURL)
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
+	}
+
+	return err
+}
+
+type urlCacheEntry struct {
+	URL       string    `json:"url"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+func (v URLValidator) cachePath(sourceURL string) string {
+	urlDigest := sha256.Sum256([]byte(sourceURL))
+	return filepath.Join(v.CacheDir, hex.EncodeToString(urlDigest[:])+".json")
+}
+
+func (v URLValidator) isCached(sourceURL string) bool {
+	if v.CacheDir == "" {
+		return false
+	}
+
+	content, err := ioutil.ReadFile(v.cachePath(sourceURL))
+	if err != nil {
+		return false
+	}
+
+	var entry urlCacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil || entry.URL != sourceURL {
+		return false
+	}
+
+	return time.Since(entry.CheckedAt) < v.CacheTTL
+}
+
+// cache records that the url was reachable. Failing to write the cache only
+// means the url is checked again next time.
+func (v URLValidator) cache(sourceURL string) {
+	if v.CacheDir == "" {
+		return
+	}
+
+	content, err := json.Marshal(urlCacheEntry{URL: sourceURL, CheckedAt: time.Now().UTC()})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(v.CacheDir, 0755); err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(v.cachePath(sourceURL), content, 0644)
+}
+
+// isAllowedHost matches the host against host names, or patterns such as
+// *.example.com.
+func isAllowedHost(host string, allowedHosts []string) bool {
+	for _, allowedHost := range allowedHosts {
+		if ok, _ := path.Match(strings.ToLower(allowedHost), strings.ToLower(host)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/additionalsources"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+)
+
+var _ = Describe("URLValidator", func() {
+	var (
+		mutex    sync.Mutex
+		requests map[string]int
+	)
+
+	// headReturning answers each url with the given status codes in turn,
+	// repeating the last one
+	headReturning := func(statusCodes ...int) HTTPHeadFn {
+		return func(u string) (*http.Response, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			i := requests[u]
+			requests[u]++
+			if i >= len(statusCodes) {
+				i = len(statusCodes) - 1
+			}
+			return &http.Response{StatusCode: statusCodes[i]}, nil
+		}
+	}
+
+	BeforeEach(func() {
+		requests = map[string]int{}
+	})
+
+	DescribeTable("ValidateOffline", func(allowedHosts []string, url, expectedError string) {
+		err := URLValidator{AllowedHosts: allowedHosts}.ValidateOffline(url)
+		if expectedError == "" {
+			Expect(err).ToNot(HaveOccurred())
+		} else {
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		}
+	},
+		Entry("accepts an http url", nil, "http://example.com/source.tgz", ""),
+		Entry("accepts a url on an allowed host", []string{"example.com"}, "https://example.com/source.tgz", ""),
+		Entry("accepts a url matching an allowed host pattern", []string{"*.example.com"}, "https://downloads.example.com:8443/source.tgz", ""),
+		Entry("rejects a url on another host", []string{"*.example.com"}, "https://example.org/source.tgz", "host example.org of url https://example.org/source.tgz is not one of the allowed hosts: *.example.com"),
+		Entry("rejects a url which is not http", nil, "ftp://example.com/source.tgz", "expected an http or https url"),
+		Entry("rejects a url without host", nil, "source.tgz", "expected an http or https url"),
+		Entry("leaves the extension to the online checks", nil, "http://example.com/source.txt", ""),
+	)
+
+	Describe("Validate", func() {
+		It("does not reach the urls when offline", func() {
+			errs := URLValidator{Offline: true, Head: headReturning(404)}.Validate([]string{
+				"http://example.com/source.tgz",
+				"http://example.com/source.txt",
+			})
+
+			Expect(errs[0]).ToNot(HaveOccurred())
+			Expect(errs[1]).To(MatchError(ContainSubstring("unsupported extension")))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("recognizes archives without a known extension from the response", func() {
+			errs := URLValidator{Head: func(u string) (*http.Response, error) {
+				header := http.Header{}
+				switch u {
+				case "https://github.com/example/project/archive/refs/tags/v1.2":
+					header.Set("Content-Type", "application/x-gzip")
+				case "https://example.com/download?id=1":
+					header.Set("Content-Type", "application/octet-stream")
+					header.Set("Content-Disposition", `attachment; filename="project-1.2.crate"`)
+				default:
+					header.Set("Content-Type", "text/html; charset=utf-8")
+				}
+				return &http.Response{StatusCode: 200, Header: header}, nil
+			}}.Validate([]string{
+				"https://github.com/example/project/archive/refs/tags/v1.2",
+				"https://example.com/download?id=1",
+				"https://example.com/index",
+			})
+
+			Expect(errs[0]).ToNot(HaveOccurred())
+			Expect(errs[1]).ToNot(HaveOccurred())
+			Expect(errs[2]).To(MatchError(`unsupported archive type for url https://example.com/index: neither its extension nor its content type "text/html; charset=utf-8" is a known archive format`))
+		})
+
+		It("recognizes registered formats", func() {
+			validator := URLValidator{
+				Offline: true,
+				Formats: append(DefaultArchiveFormats(), ArchiveFormat{Extension: "src.bundle"}),
+			}
+			errs := validator.Validate([]string{
+				"http://example.com/source.src.bundle",
+				"http://example.com/source.jar",
+			})
+
+			Expect(errs[0]).ToNot(HaveOccurred())
+			Expect(errs[1]).ToNot(HaveOccurred())
+		})
+
+		It("returns the errors in the order of the urls", func() {
+			errs := URLValidator{Head: func(u string) (*http.Response, error) {
+				if u == "http://example.com/missing.tgz" {
+					return &http.Response{StatusCode: 404}, nil
+				}
+				return &http.Response{StatusCode: 200}, nil
+			}}.Validate([]string{
+				"http://example.com/a.tgz",
+				"http://example.com/missing.tgz",
+				"http://example.com/b.tgz",
+			})
+
+			Expect(errs[0]).ToNot(HaveOccurred())
+			Expect(errs[1]).To(MatchError("got status code 404 when trying to reach http://example.com/missing.tgz (expected 2xx)"))
+			Expect(errs[2]).ToNot(HaveOccurred())
+		})
+
+		It("retries server errors", func() {
+			errs := URLValidator{Head: headReturning(503, 502, 200), Retries: 2}.Validate([]string{"http://example.com/source.tgz"})
+
+			Expect(errs[0]).ToNot(HaveOccurred())
+			Expect(requests["http://example.com/source.tgz"]).To(Equal(3))
+		})
+
+		It("retries network errors and reports the last one", func() {
+			attempts := 0
+			errs := URLValidator{Retries: 1, Head: func(u string) (*http.Response, error) {
+				attempts++
+				return nil, fmt.Errorf("connection refused %d", attempts)
+			}}.Validate([]string{"http://example.com/source.tgz"})
+
+			Expect(errs[0]).To(MatchError("invalid url: connection refused 2"))
+		})
+
+		It("does not retry client errors", func() {
+			errs := URLValidator{Head: headReturning(404), Retries: 2}.Validate([]string{"http://example.com/source.tgz"})
+
+			Expect(errs[0]).To(HaveOccurred())
+			Expect(requests["http://example.com/source.tgz"]).To(Equal(1))
+		})
+
+		Context("with a cache directory", func() {
+			var cacheDir string
+
+			BeforeEach(func() {
+				var err error
+				cacheDir, err = ioutil.TempDir("", "url-cache-")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(cacheDir)).To(Succeed())
+			})
+
+			It("does not reach a url again within the ttl", func() {
+				validator := URLValidator{Head: headReturning(200), CacheDir: cacheDir, CacheTTL: time.Hour}
+				Expect(validator.Validate([]string{"http://example.com/source.tgz"})[0]).ToNot(HaveOccurred())
+				Expect(validator.Validate([]string{"http://example.com/source.tgz"})[0]).ToNot(HaveOccurred())
+
+				Expect(requests["http://example.com/source.tgz"]).To(Equal(1))
+			})
+
+			It("reaches a url again once the ttl expired", func() {
+				validator := URLValidator{Head: headReturning(200), CacheDir: cacheDir, CacheTTL: 0}
+				Expect(validator.Validate([]string{"http://example.com/source.tgz"})[0]).ToNot(HaveOccurred())
+				Expect(validator.Validate([]string{"http://example.com/source.tgz"})[0]).ToNot(HaveOccurred())
+
+				Expect(requests["http://example.com/source.tgz"]).To(Equal(2))
+			})
+
+			It("does not cache unreachable urls", func() {
+				validator := URLValidator{Head: headReturning(404, 200), CacheDir: cacheDir, CacheTTL: time.Hour}
+				Expect(validator.Validate([]string{"http://example.com/source.tgz"})[0]).To(HaveOccurred())
+				Expect(validator.Validate([]string{"http://example.com/source.tgz"})[0]).ToNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("NewURLValidator", func() {
+		It("returns an error for an invalid proxy url", func() {
+			_, err := NewURLValidator(common.RunParams{Proxy: "http://[::1"})
+			Expect(err).To(MatchError(ContainSubstring("invalid proxy url")))
+		})
+	})
+})
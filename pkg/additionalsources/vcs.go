@@ -0,0 +1,87 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources
+
+import (
+	"regexp"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+var (
+	hgURLPattern       = regexp.MustCompile(`^(https?|ssh)://[\w\.@:/\-~]+$`)
+	hgChangesetPattern = regexp.MustCompile(`^([0-9a-f]{12}|[0-9a-f]{40})$`)
+
+	svnURLPattern      = regexp.MustCompile(`^(svn|svn\+ssh|https?)://[\w\.@:/\-~]+$`)
+	svnRevisionPattern = regexp.MustCompile(`^[0-9]+$`)
+
+	// the first element of a module path is a domain name
+	goModulePathPattern = regexp.MustCompile(`^[a-z0-9\-]+(\.[a-z0-9\-]+)+(/[\w\.\-~]+)*$`)
+	goVersionPattern    = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+(-[0-9A-Za-z\.\-]+)?(\+incompatible)?$`)
+	goSumHashPattern    = regexp.MustCompile(`^h1:[A-Za-z0-9+/]{43}=$`)
+)
+
+// IsValidHgDependency checks that the url is a remote Mercurial repository and
+// the version a short or full changeset id.
+func IsValidHgDependency(hgUrl, changeset string) bool {
+	return hgURLPattern.MatchString(hgUrl) && hgChangesetPattern.MatchString(changeset)
+}
+
+// IsValidSvnDependency checks that the url is a remote Subversion repository
+// path and the version a revision number.
+func IsValidSvnDependency(svnUrl, revision string) bool {
+	return svnURLPattern.MatchString(svnUrl) && svnRevisionPattern.MatchString(revision)
+}
+
+// IsValidGoDependency checks the module path, the semantic or pseudo version
+// and the go.sum hash of a Go module.
+func IsValidGoDependency(modulePath, version, hash string) bool {
+	return goModulePathPattern.MatchString(modulePath) && goVersionPattern.MatchString(version) && goSumHashPattern.MatchString(hash)
+}
+
+func CreateHgDependency(vcs AdditionalSourceVcs) metadata.Dependency {
+	return metadata.Dependency{
+		Type: "package",
+		Source: metadata.Source{
+			Type: metadata.HgSourceType,
+			Version: map[string]interface{}{
+				"changeset": vcs.Version,
+			},
+			Metadata: metadata.HgSourceMetadata{
+				URL: vcs.Url,
+			},
+		},
+	}
+}
+
+func CreateSvnDependency(vcs AdditionalSourceVcs) metadata.Dependency {
+	return metadata.Dependency{
+		Type: "package",
+		Source: metadata.Source{
+			Type: metadata.SvnSourceType,
+			Version: map[string]interface{}{
+				"revision": vcs.Version,
+			},
+			Metadata: metadata.SvnSourceMetadata{
+				URL: vcs.Url,
+			},
+		},
+	}
+}
+
+func CreateGoDependency(vcs AdditionalSourceVcs) metadata.Dependency {
+	return metadata.Dependency{
+		Type: "package",
+		Source: metadata.Source{
+			Type: metadata.GoModuleSourceType,
+			Version: map[string]interface{}{
+				"version": vcs.Version,
+			},
+			Metadata: metadata.GoModuleSourceMetadata{
+				Path: vcs.Url,
+				Hash: vcs.Hash,
+			},
+		},
+	}
+}
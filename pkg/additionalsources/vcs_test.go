@@ -0,0 +1,135 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package additionalsources_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/additionalsources"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+const goSumHash = "h1:olpwvP2KacW1ZWvsR7uQhoyTYvKAupfQrRGBFM352Gk="
+
+var _ = Describe("vcs", func() {
+	DescribeTable("IsValidHgDependency", func(url, changeset string, valid bool) {
+		Expect(IsValidHgDependency(url, changeset)).To(Equal(valid))
+	},
+		Entry("with a full changeset id", "https://hg.example.com/repo", "0123456789abcdef0123456789abcdef01234567", true),
+		Entry("with a short changeset id", "ssh://hg@hg.example.com/repo", "0123456789ab", true),
+		Entry("with a revision number", "https://hg.example.com/repo", "42", false),
+		Entry("with a url without scheme", "hg.example.com/repo", "0123456789ab", false),
+	)
+
+	DescribeTable("IsValidSvnDependency", func(url, revision string, valid bool) {
+		Expect(IsValidSvnDependency(url, revision)).To(Equal(valid))
+	},
+		Entry("with a svn url", "svn://svn.example.com/repo/trunk", "1234", true),
+		Entry("with a svn+ssh url", "svn+ssh://svn.example.com/repo/tags/1.0", "1", true),
+		Entry("with an https url", "https://svn.example.com/repo/trunk", "1234", true),
+		Entry("with a non numeric revision", "svn://svn.example.com/repo/trunk", "HEAD", false),
+		Entry("with a git url", "git@github.com:org/repo.git", "1234", false),
+	)
+
+	DescribeTable("IsValidGoDependency", func(modulePath, version, hash string, valid bool) {
+		Expect(IsValidGoDependency(modulePath, version, hash)).To(Equal(valid))
+	},
+		Entry("with a semantic version", "golang.org/x/text", "v0.3.7", goSumHash, true),
+		Entry("with a pseudo version", "github.com/org/repo/v2", "v2.0.0-20220315160706-3147a52a75dd", goSumHash, true),
+		Entry("with an incompatible version", "github.com/docker/docker", "v20.10.17+incompatible", goSumHash, true),
+		Entry("with a module path without domain", "text", "v0.3.7", goSumHash, false),
+		Entry("with a version without v prefix", "golang.org/x/text", "0.3.7", goSumHash, false),
+		Entry("without a hash", "golang.org/x/text", "v0.3.7", "", false),
+		Entry("with a go.mod hash of the wrong format", "golang.org/x/text", "v0.3.7", "h1:abc=", false),
+	)
+
+	Describe("ParseAdditionalSourcesFile", func() {
+		var sourcesFilePath string
+
+		writeSourcesFile := func(content string) {
+			f, err := ioutil.TempFile("", "sources-file-")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.WriteString(content)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+			sourcesFilePath = f.Name()
+		}
+
+		AfterEach(func() {
+			Expect(os.Remove(sourcesFilePath)).To(Succeed())
+		})
+
+		It("creates a dependency for each vcs protocol", func() {
+			writeSourcesFile(`version: 2
+vcs:
+  - protocol: hg
+    version: 0123456789ab
+    url: https://hg.example.com/repo
+  - protocol: svn
+    version: "1234"
+    url: svn://svn.example.com/repo/trunk
+  - protocol: go
+    version: v0.3.7
+    url: golang.org/x/text
+    hash: ` + goSumHash + `
+`)
+
+			_, dependencies, err := ParseAdditionalSourcesFile(sourcesFilePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dependencies).To(Equal([]metadata.Dependency{
+				{
+					Type: "package",
+					Source: metadata.Source{
+						Type:     metadata.HgSourceType,
+						Version:  map[string]interface{}{"changeset": "0123456789ab"},
+						Metadata: metadata.HgSourceMetadata{URL: "https://hg.example.com/repo"},
+					},
+				},
+				{
+					Type: "package",
+					Source: metadata.Source{
+						Type:     metadata.SvnSourceType,
+						Version:  map[string]interface{}{"revision": "1234"},
+						Metadata: metadata.SvnSourceMetadata{URL: "svn://svn.example.com/repo/trunk"},
+					},
+				},
+				{
+					Type: "package",
+					Source: metadata.Source{
+						Type:     metadata.GoModuleSourceType,
+						Version:  map[string]interface{}{"version": "v0.3.7"},
+						Metadata: metadata.GoModuleSourceMetadata{Path: "golang.org/x/text", Hash: goSumHash},
+					},
+				},
+			}))
+		})
+
+		It("reports entries in an unsupported format", func() {
+			writeSourcesFile(`version: 2
+vcs:
+  - protocol: hg
+    version: "42"
+    url: https://hg.example.com/repo
+  - protocol: go
+    version: v0.3.7
+    url: golang.org/x/text
+  - protocol: git
+    version: abc123
+    url: git@github.com:org/repo.git
+    hash: ` + goSumHash + `
+`)
+
+			_, _, err := ParseAdditionalSourcesFile(sourcesFilePath)
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring("vcs hg url or changeset in an unsupported format: https://hg.example.com/repo 42"),
+				ContainSubstring("vcs go module path, version or hash in an unsupported format: golang.org/x/text v0.3.7"),
+				ContainSubstring("vcs git does not support a hash: git@github.com:org/repo.git"),
+			)))
+		})
+	})
+})
@@ -0,0 +1,122 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package baseimage
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"gopkg.in/yaml.v2"
+)
+
+// Candidate is an image which may be the base of the input image, with the
+// diff ids of its layers.
+type Candidate struct {
+	Name    string   `yaml:"name"`
+	Digest  string   `yaml:"digest"`
+	DiffIDs []string `yaml:"diff_ids"`
+}
+
+// Catalog lists candidate base images, so that they do not need to be pulled
+// or loaded when labeling.
+type Catalog struct {
+	BaseImages []Candidate `yaml:"base_images"`
+}
+
+// LoadCandidates loads the candidate base images given either as the path to
+// an image tarball or as an image reference, and those of the catalog files.
+func LoadCandidates(baseImages []string, catalogPaths []string) ([]Candidate, error) {
+	var candidates []Candidate
+
+	for _, baseImage := range baseImages {
+		candidate, err := loadCandidate(baseImage)
+		if err != nil {
+			return nil, fmt.Errorf("could not load base image %s: %w", baseImage, err)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	for _, catalogPath := range catalogPaths {
+		catalog, err := ParseCatalogFile(catalogPath)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, catalog.BaseImages...)
+	}
+
+	return candidates, nil
+}
+
+func loadCandidate(baseImage string) (Candidate, error) {
+	var (
+		image v1.Image
+		name  = baseImage
+		err   error
+	)
+
+	if _, statErr := os.Stat(baseImage); statErr == nil {
+		image, err = crane.Load(baseImage)
+		if err != nil {
+			return Candidate{}, err
+		}
+
+		// the tag saved with the image is a better name than the path
+		manifest, err := tarball.LoadManifest(func() (io.ReadCloser, error) {
+			return os.Open(baseImage)
+		})
+		if err == nil && len(manifest) > 0 && len(manifest[0].RepoTags) > 0 {
+			name = manifest[0].RepoTags[0]
+		}
+	} else {
+		image, err = crane.Pull(baseImage)
+		if err != nil {
+			return Candidate{}, err
+		}
+	}
+
+	digest, err := image.Digest()
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	config, err := image.ConfigFile()
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	candidate := Candidate{Name: name, Digest: digest.String()}
+	for _, diffID := range config.RootFS.DiffIDs {
+		candidate.DiffIDs = append(candidate.DiffIDs, diffID.String())
+	}
+	return candidate, nil
+}
+
+func ParseCatalogFile(catalogPath string) (Catalog, error) {
+	catalogReader, err := os.Open(catalogPath)
+	if err != nil {
+		return Catalog{}, fmt.Errorf("could not open base image catalog %s: %w", catalogPath, err)
+	}
+	defer catalogReader.Close()
+
+	decoder := yaml.NewDecoder(catalogReader)
+	decoder.SetStrict(true)
+
+	var catalog Catalog
+	err = decoder.Decode(&catalog)
+	if err != nil {
+		return Catalog{}, fmt.Errorf("could not parse base image catalog %s: %w", catalogPath, err)
+	}
+
+	for _, candidate := range catalog.BaseImages {
+		if candidate.Name == "" || len(candidate.DiffIDs) == 0 {
+			return Catalog{}, fmt.Errorf("could not parse base image catalog %s: each base image requires a name and diff_ids", catalogPath)
+		}
+	}
+
+	return catalog, nil
+}
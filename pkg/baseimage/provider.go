@@ -0,0 +1,125 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package baseimage
+
+import (
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// The OCI annotations, also set as labels by some tools, naming the image an
+// image was built from.
+const (
+	BaseNameAnnotation   = "org.opencontainers.image.base.name"
+	BaseDigestAnnotation = "org.opencontainers.image.base.digest"
+)
+
+// The ways the base image was identified.
+const (
+	LayersSource     = "layers"
+	AnnotationSource = "annotation"
+	LabelSource      = "label"
+)
+
+// manifestImage is implemented by the images whose manifest is known, to read
+// its annotations.
+type manifestImage interface {
+	Manifest() (*v1.Manifest, error)
+}
+
+// Provider records the image the image was built from in its base. The
+// candidate base images given with --base-image and --base-image-catalog are
+// compared with the layers of the image; without a match, the base image is
+// read from the OCI base image annotations of the manifest, then from labels
+// of the same name.
+func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	config, err := dli.GetConfig()
+	if err != nil {
+		return metadata.Metadata{}, nil, err
+	}
+
+	candidates, err := LoadCandidates(params.BaseImages, params.BaseImageCatalogPaths)
+	if err != nil {
+		return metadata.Metadata{}, nil, err
+	}
+
+	var diffIDs []string
+	for _, diffID := range config.RootFS.DiffIDs {
+		diffIDs = append(diffIDs, diffID.String())
+	}
+
+	var base metadata.Base
+	if candidate, ok := Match(diffIDs, candidates); ok {
+		base = baseImage(candidate.Name, candidate.Digest, LayersSource)
+	} else if annotations := manifestAnnotations(dli); annotations[BaseNameAnnotation] != "" || annotations[BaseDigestAnnotation] != "" {
+		base = baseImage(annotations[BaseNameAnnotation], annotations[BaseDigestAnnotation], AnnotationSource)
+	} else if labels := config.Config.Labels; labels[BaseNameAnnotation] != "" || labels[BaseDigestAnnotation] != "" {
+		base = baseImage(labels[BaseNameAnnotation], labels[BaseDigestAnnotation], LabelSource)
+	} else {
+		return md, nil, nil
+	}
+
+	md.Base = metadata.MergeBase(md.Base, base)
+	return md, nil, nil
+}
+
+// Match returns the candidate whose layers are the first layers of the image.
+// When several candidates match, e.g. an image and the image it was itself
+// built from, the one with the most layers is the closest base.
+func Match(diffIDs []string, candidates []Candidate) (Candidate, bool) {
+	var (
+		match Candidate
+		found bool
+	)
+
+	for _, candidate := range candidates {
+		if !isPrefix(candidate.DiffIDs, diffIDs) {
+			continue
+		}
+		if !found || len(candidate.DiffIDs) > len(match.DiffIDs) {
+			match, found = candidate, true
+		}
+	}
+
+	return match, found
+}
+
+func isPrefix(prefix, diffIDs []string) bool {
+	if len(prefix) == 0 || len(prefix) > len(diffIDs) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != diffIDs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func manifestAnnotations(dli image.Image) map[string]string {
+	mi, ok := dli.(manifestImage)
+	if !ok {
+		return nil
+	}
+
+	manifest, err := mi.Manifest()
+	if err != nil {
+		return nil
+	}
+	return manifest.Annotations
+}
+
+func baseImage(name, digest, source string) metadata.Base {
+	base := metadata.Base{metadata.BaseImageSourceKey: source}
+	if name != "" {
+		base[metadata.BaseImageKey] = name
+	}
+	if digest != "" {
+		base[metadata.BaseImageDigestKey] = digest
+	}
+	return base
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package baseimage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/baseimage"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/test/test_utils"
+)
+
+type annotatedImage struct {
+	test_utils.MockImage
+	annotations map[string]string
+}
+
+func (m annotatedImage) Manifest() (*v1.Manifest, error) {
+	return &v1.Manifest{Annotations: m.annotations}, nil
+}
+
+func imageWithDiffIDs(labels map[string]string, diffIDs ...string) test_utils.MockImage {
+	config := &v1.ConfigFile{}
+	config.Config.Labels = labels
+	for _, diffID := range diffIDs {
+		config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, v1.Hash{Algorithm: "sha256", Hex: diffID})
+	}
+	return test_utils.NewMockImageWithConfig(config)
+}
+
+var _ = Describe("BaseImage", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "deplab-base-image")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	writeCatalog := func(content string) string {
+		path := filepath.Join(tempDir, "catalog.yml")
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	Describe("Match", func() {
+		debian := Candidate{Name: "debian:10", DiffIDs: []string{"a"}}
+		base := Candidate{Name: "our-registry/base:2024.03", DiffIDs: []string{"a", "b"}}
+		other := Candidate{Name: "other", DiffIDs: []string{"c"}}
+
+		It("returns the candidate with the most layers in common with the image", func() {
+			match, ok := Match([]string{"a", "b", "c"}, []Candidate{debian, base, other})
+			Expect(ok).To(BeTrue())
+			Expect(match).To(Equal(base))
+		})
+
+		It("matches an image identical to the candidate", func() {
+			match, ok := Match([]string{"a"}, []Candidate{debian})
+			Expect(ok).To(BeTrue())
+			Expect(match).To(Equal(debian))
+		})
+
+		It("does not match candidates with layers the image does not start with", func() {
+			_, ok := Match([]string{"b", "a"}, []Candidate{debian, base, other})
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("Provider", func() {
+		It("records the candidate of the catalog the image was built from", func() {
+			catalogPath := writeCatalog(`base_images:
+- name: debian:10
+  digest: sha256:debian
+  diff_ids: [sha256:a]
+- name: our-registry/base:2024.03
+  digest: sha256:base
+  diff_ids: [sha256:a, sha256:b]
+`)
+
+			md, _, err := Provider(imageWithDiffIDs(nil, "a", "b", "c"), common.RunParams{BaseImageCatalogPaths: []string{catalogPath}}, metadata.Metadata{
+				Base: metadata.Base{"name": "debian"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(md.Base).To(Equal(metadata.Base{
+				"name":              "debian",
+				"base_image":        "our-registry/base:2024.03",
+				"base_image_digest": "sha256:base",
+				"base_image_source": "layers",
+			}))
+		})
+
+		It("records a base image tarball the image was built from", func() {
+			base, err := random.Image(64, 2)
+			Expect(err).ToNot(HaveOccurred())
+			layer, err := random.Layer(64, "application/vnd.docker.image.rootfs.diff.tar.gzip")
+			Expect(err).ToNot(HaveOccurred())
+			img, err := mutate.AppendLayers(base, layer)
+			Expect(err).ToNot(HaveOccurred())
+
+			tag, err := name.NewTag("our-registry/base:2024.03")
+			Expect(err).ToNot(HaveOccurred())
+			tarPath := filepath.Join(tempDir, "base.tar")
+			Expect(tarball.WriteToFile(tarPath, tag, base)).To(Succeed())
+
+			config, err := img.ConfigFile()
+			Expect(err).ToNot(HaveOccurred())
+			digest, err := base.Digest()
+			Expect(err).ToNot(HaveOccurred())
+
+			md, _, err := Provider(test_utils.NewMockImageWithConfig(config), common.RunParams{BaseImages: []string{tarPath}}, metadata.Metadata{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(md.Base).To(Equal(metadata.Base{
+				"base_image":        "our-registry/base:2024.03",
+				"base_image_digest": digest.String(),
+				"base_image_source": "layers",
+			}))
+		})
+
+		It("records the base image annotations of the manifest", func() {
+			dli := annotatedImage{
+				MockImage: imageWithDiffIDs(map[string]string{BaseNameAnnotation: "from-label"}, "a"),
+				annotations: map[string]string{
+					BaseNameAnnotation:   "docker.io/library/debian:10",
+					BaseDigestAnnotation: "sha256:debian",
+				},
+			}
+
+			md, _, err := Provider(dli, common.RunParams{}, metadata.Metadata{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(md.Base).To(Equal(metadata.Base{
+				"base_image":        "docker.io/library/debian:10",
+				"base_image_digest": "sha256:debian",
+				"base_image_source": "annotation",
+			}))
+		})
+
+		It("records the base image labels", func() {
+			md, _, err := Provider(imageWithDiffIDs(map[string]string{BaseNameAnnotation: "docker.io/library/debian:10"}, "a"), common.RunParams{}, metadata.Metadata{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(md.Base).To(Equal(metadata.Base{
+				"base_image":        "docker.io/library/debian:10",
+				"base_image_source": "label",
+			}))
+		})
+
+		It("does not modify the metadata when the base image is not known", func() {
+			Expect(Provider(imageWithDiffIDs(nil, "a"), common.RunParams{}, metadata.Metadata{})).To(Equal(metadata.Metadata{}))
+		})
+
+		It("returns an error when a catalog is malformed", func() {
+			catalogPath := writeCatalog(`base_images:
+- name: debian:10
+`)
+
+			_, _, err := Provider(imageWithDiffIDs(nil, "a"), common.RunParams{BaseImageCatalogPaths: []string{catalogPath}}, metadata.Metadata{})
+			Expect(err).To(MatchError(ContainSubstring("each base image requires a name and diff_ids")))
+		})
+	})
+})
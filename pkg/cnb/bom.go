@@ -0,0 +1,109 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package cnb
+
+import (
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// normalizeBOM copies the metadata keys written by the Paketo buildpacks to the
+// typed fields of the bill of materials entry. The metadata is left as it is,
+// for the consumers of the keys, and keys with an unexpected value are not
+// copied.
+func normalizeBOM(bom *metadata.BuildpackBOM) {
+	if bom.Metadata == nil {
+		return
+	}
+
+	if uri, ok := bom.Metadata["uri"].(string); ok {
+		bom.URI = uri
+	}
+
+	if sha256, ok := bom.Metadata["sha256"].(string); ok {
+		bom.Sha256 = sha256
+	}
+
+	if purl, ok := bom.Metadata["purl"].(string); ok {
+		bom.PURL = purl
+	}
+
+	if licenses, ok := bomLicenses(bom.Metadata["licenses"]); ok {
+		bom.Licenses = licenses
+	}
+
+	// newer buildpacks write a single cpe, older ones a list
+	if cpe, ok := bom.Metadata["cpe"].(string); ok {
+		bom.CPEs = append(bom.CPEs, cpe)
+	}
+	if cpes, ok := stringList(bom.Metadata["cpes"]); ok {
+		bom.CPEs = append(bom.CPEs, cpes...)
+	}
+
+	if stacks, ok := stringList(bom.Metadata["stacks"]); ok {
+		bom.Stacks = stacks
+	}
+}
+
+// withoutTypedBOMFields returns the buildpack metadata without the typed
+// fields copied from the metadata keys of the bill of materials entries, so
+// that its digest is the digest of the metadata as recorded before they were
+// added.
+func withoutTypedBOMFields(bp metadata.BuildpackBOMSourceMetadata) metadata.BuildpackBOMSourceMetadata {
+	boms := make([]metadata.BuildpackBOM, 0, len(bp.BillOfMaterials))
+	for _, bom := range bp.BillOfMaterials {
+		boms = append(boms, metadata.BuildpackBOM{
+			Name:      bom.Name,
+			Version:   bom.Version,
+			Metadata:  bom.Metadata,
+			Buildpack: bom.Buildpack,
+			Layers:    bom.Layers,
+		})
+	}
+	bp.BillOfMaterials = boms
+	return bp
+}
+
+// bomLicenses reads licenses written either as objects with a type and a uri,
+// or as plain SPDX identifiers.
+func bomLicenses(value interface{}) ([]metadata.BuildpackLicense, bool) {
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	licenses := []metadata.BuildpackLicense{}
+	for _, entry := range entries {
+		switch license := entry.(type) {
+		case string:
+			licenses = append(licenses, metadata.BuildpackLicense{Type: license})
+		case map[string]interface{}:
+			licenseType, ok := license["type"].(string)
+			if !ok {
+				return nil, false
+			}
+			uri, _ := license["uri"].(string)
+			licenses = append(licenses, metadata.BuildpackLicense{Type: licenseType, URI: uri})
+		default:
+			return nil, false
+		}
+	}
+	return licenses, true
+}
+
+func stringList(value interface{}) ([]string, bool) {
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	list := []string{}
+	for _, entry := range entries {
+		s, ok := entry.(string)
+		if !ok {
+			return nil, false
+		}
+		list = append(list, s)
+	}
+	return list, true
+}
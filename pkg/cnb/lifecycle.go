@@ -0,0 +1,146 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package cnb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+const (
+	BuildMetadataLabel     = "io.buildpacks.build.metadata"
+	LifecycleMetadataLabel = "io.buildpacks.lifecycle.metadata"
+	StackIDLabel           = "io.buildpacks.stack.id"
+	StackMixinsLabel       = "io.buildpacks.stack.mixins"
+)
+
+// lifecycleMetadata is the part of the io.buildpacks.lifecycle.metadata label
+// recorded by deplab.
+type lifecycleMetadata struct {
+	Buildpacks []lifecycleBuildpack `json:"buildpacks"`
+	RunImage   struct {
+		TopLayer  string `json:"topLayer"`
+		Reference string `json:"reference"`
+	} `json:"runImage"`
+	Stack struct {
+		RunImage struct {
+			Image   string   `json:"image"`
+			Mirrors []string `json:"mirrors"`
+		} `json:"runImage"`
+	} `json:"stack"`
+}
+
+type lifecycleBuildpack struct {
+	Key     string                    `json:"key"`
+	ID      string                    `json:"id"`
+	Version string                    `json:"version"`
+	Layers  map[string]lifecycleLayer `json:"layers"`
+}
+
+type lifecycleLayer struct {
+	SHA    string `json:"sha"`
+	Build  bool   `json:"build"`
+	Launch bool   `json:"launch"`
+	Cache  bool   `json:"cache"`
+}
+
+// addLifecycleMetadata adds the run image and the layers of each buildpack to
+// the buildpack metadata, and links the bill of materials entries to the
+// layers of their buildpack.
+func addLifecycleMetadata(bp *metadata.BuildpackBOMSourceMetadata, lifecycleMetadataContents string) error {
+	var lifecycle lifecycleMetadata
+	err := json.Unmarshal([]byte(lifecycleMetadataContents), &lifecycle)
+	if err != nil {
+		return fmt.Errorf("could not decode json: %w", err)
+	}
+
+	runImage := metadata.BuildpackRunImage{
+		Image:     lifecycle.Stack.RunImage.Image,
+		Mirrors:   lifecycle.Stack.RunImage.Mirrors,
+		Reference: lifecycle.RunImage.Reference,
+		TopLayer:  lifecycle.RunImage.TopLayer,
+	}
+	if runImage.Image != "" || runImage.Reference != "" {
+		bp.RunImage = &runImage
+	}
+
+	layersByBuildpack := map[string][]string{}
+	for _, buildpack := range lifecycle.Buildpacks {
+		id := buildpack.Key
+		if id == "" {
+			id = buildpack.ID
+		}
+
+		for name, layer := range buildpack.Layers {
+			if layer.SHA == "" {
+				continue
+			}
+			bp.Layers = append(bp.Layers, metadata.BuildpackLayer{
+				Name:      name,
+				Digest:    layer.SHA,
+				Buildpack: metadata.Buildpack{ID: id, Version: buildpack.Version},
+				Build:     layer.Build,
+				Launch:    layer.Launch,
+				Cache:     layer.Cache,
+			})
+			layersByBuildpack[id] = append(layersByBuildpack[id], layer.SHA)
+		}
+	}
+
+	sort.Slice(bp.Layers, func(i, j int) bool {
+		if bp.Layers[i].Buildpack.ID != bp.Layers[j].Buildpack.ID {
+			return bp.Layers[i].Buildpack.ID < bp.Layers[j].Buildpack.ID
+		}
+		return bp.Layers[i].Name < bp.Layers[j].Name
+	})
+
+	for i, bom := range bp.BillOfMaterials {
+		layers := layersByBuildpack[bom.Buildpack.ID]
+		sort.Strings(layers)
+		bp.BillOfMaterials[i].Layers = layers
+	}
+
+	return nil
+}
+
+// stack returns the stack of the image from its labels, if any.
+func stack(labels map[string]string) (*metadata.BuildpackStack, error) {
+	id := labels[StackIDLabel]
+	if id == "" {
+		return nil, nil
+	}
+
+	var mixins []string
+	if mixinsContents := labels[StackMixinsLabel]; mixinsContents != "" {
+		err := json.Unmarshal([]byte(mixinsContents), &mixins)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode stack mixins json: %w", err)
+		}
+		sort.Strings(mixins)
+	}
+
+	return &metadata.BuildpackStack{ID: id, Mixins: mixins}, nil
+}
+
+// runImageBase is the part of the base of the image describing its run image.
+func runImageBase(runImage *metadata.BuildpackRunImage) metadata.Base {
+	base := metadata.Base{}
+	if runImage == nil {
+		return base
+	}
+
+	reference := runImage.Reference
+	if reference == "" {
+		reference = runImage.Image
+	}
+	base["run_image"] = reference
+	if i := strings.Index(reference, "@"); i >= 0 {
+		base["run_image_digest"] = reference[i+1:]
+	}
+	return base
+}
@@ -17,39 +17,70 @@ import (
 	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
 )
 
-func Provider(dli image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
+// Provider records the build metadata of a Cloud Native Buildpacks image,
+// with its stack, run image, the layers contributed by each buildpack and the
+// components of the SBOM files written by the buildpacks. The run image is
+// also recorded in the base of the image.
+func Provider(dli image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
 	var buildpackMetadataContents string
-	config, err := dli.GetConfig()
 
+	config, err := dli.GetConfig()
 	if err != nil {
-		return metadata.Metadata{}, err
+		return metadata.Metadata{}, nil, err
 	}
+	labels := config.Config.Labels
 
-	buildpackMetadataContents = config.Config.Labels["io.buildpacks.build.metadata"]
+	buildpackMetadataContents = labels[BuildMetadataLabel]
+	lifecycleMetadataContents := labels[LifecycleMetadataLabel]
 
+	buildpackMetadata := metadata.BuildpackBOMSourceMetadata{}
 	if buildpackMetadataContents != "" {
-		buildpackMetadata, err := parseMetadataJSON(buildpackMetadataContents)
+		buildpackMetadata, err = parseMetadataJSON(buildpackMetadataContents)
 		if err != nil {
-			return metadata.Metadata{}, fmt.Errorf("could not parse buildpack metadata toml: %w", err)
+			return metadata.Metadata{}, nil, fmt.Errorf("could not parse buildpack metadata toml: %w", err)
 		}
+	}
 
-		version, err := common.Digest(buildpackMetadata)
+	components, warnings := launchSBOMComponents(dli, buildpackMetadata.Buildpacks)
+	buildpackMetadata.Components = components
+
+	if buildpackMetadataContents == "" && lifecycleMetadataContents == "" && labels[StackIDLabel] == "" && len(components) == 0 {
+		return md, warnings, nil
+	}
+
+	if lifecycleMetadataContents != "" {
+		err = addLifecycleMetadata(&buildpackMetadata, lifecycleMetadataContents)
 		if err != nil {
-			return metadata.Metadata{}, fmt.Errorf("could not get digest for buildpack metadata: %w", err)
+			return metadata.Metadata{}, nil, fmt.Errorf("could not parse lifecycle metadata: %w", err)
 		}
+	}
+
+	buildpackMetadata.Stack, err = stack(labels)
+	if err != nil {
+		return metadata.Metadata{}, nil, err
+	}
 
-		md.Dependencies = append(md.Dependencies, metadata.Dependency{
-			Type: metadata.BuildpackMetadataType,
-			Source: metadata.Source{
-				Type:     "inline",
-				Metadata: buildpackMetadata,
-				Version: map[string]interface{}{
-					"sha256": version,
-				},
+	version, err := common.Digest(withoutTypedBOMFields(buildpackMetadata))
+	if err != nil {
+		return metadata.Metadata{}, nil, fmt.Errorf("could not get digest for buildpack metadata: %w", err)
+	}
+
+	md.Dependencies = append(md.Dependencies, metadata.Dependency{
+		Type: metadata.BuildpackMetadataType,
+		Source: metadata.Source{
+			Type:     "inline",
+			Metadata: buildpackMetadata,
+			Version: map[string]interface{}{
+				"sha256": version,
 			},
-		})
+		},
+	})
+
+	if buildpackMetadata.RunImage != nil {
+		md.Base = metadata.MergeBase(md.Base, runImageBase(buildpackMetadata.RunImage))
 	}
-	return md, nil
+
+	return md, warnings, nil
 }
 
 func parseMetadataJSON(buildpackMetadata string) (metadata.BuildpackBOMSourceMetadata, error) {
@@ -60,6 +91,10 @@ func parseMetadataJSON(buildpackMetadata string) (metadata.BuildpackBOMSourceMet
 		return metadata.BuildpackBOMSourceMetadata{}, fmt.Errorf("could not decode json: %w", err)
 	}
 
+	for i := range bp.BillOfMaterials {
+		normalizeBOM(&bp.BillOfMaterials[i])
+	}
+
 	collator := collate.New(language.BritishEnglish)
 	sort.Slice(bp.Buildpacks, func(i, j int) bool {
 		return collator.CompareString(bp.Buildpacks[i].ID, bp.Buildpacks[j].ID) < 0
@@ -19,5 +19,132 @@ var _ = Describe("Cnb", func() {
 				Expect(Provider(test_utils.NewMockImageWithEmptyConfig(), common.RunParams{}, metadata.Metadata{})).To(Equal(metadata.Metadata{}))
 			})
 		})
+
+		Context("when the image has lifecycle and stack labels", func() {
+			labels := map[string]string{
+				BuildMetadataLabel: `{"bom":[{"name":"node","version":"12.16.1","metadata":{},"buildpack":{"id":"org.cloudfoundry.node-engine","version":"0.0.158"}},{"name":"yarn","version":"1.22.4","metadata":{},"buildpack":{"id":"org.cloudfoundry.yarn","version":"0.0.112"}}],"buildpacks":[{"id":"org.cloudfoundry.node-engine","version":"0.0.158"},{"id":"org.cloudfoundry.yarn","version":"0.0.112"}],"launcher":{"version":"0.6.0"}}`,
+				LifecycleMetadataLabel: `{
+  "app": [{"sha": "sha256:app"}],
+  "launcher": {"sha": "sha256:launcher"},
+  "buildpacks": [
+    {"key": "org.cloudfoundry.node-engine", "version": "0.0.158", "layers": {
+      "node": {"sha": "sha256:node", "launch": true},
+      "modules": {"sha": "sha256:modules", "launch": true, "cache": true},
+      "build-only": {"build": true}
+    }},
+    {"key": "org.cloudfoundry.yarn", "version": "0.0.112", "layers": {}}
+  ],
+  "runImage": {"topLayer": "sha256:top", "reference": "index.docker.io/cloudfoundry/run@sha256:run"},
+  "stack": {"runImage": {"image": "cloudfoundry/run:base-cnb", "mirrors": ["gcr.io/cloudfoundry/run:base-cnb"]}}
+}`,
+				StackIDLabel:     "io.buildpacks.stacks.bionic",
+				StackMixinsLabel: `["build:git", "ca-certificates"]`,
+			}
+
+			It("records the stack, the run image and the layers of each buildpack", func() {
+				md, _, err := Provider(test_utils.NewMockImageWithLabels(labels), common.RunParams{}, metadata.Metadata{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(md.Dependencies).To(HaveLen(1))
+				buildpackMetadata := md.Dependencies[0].Source.Metadata.(metadata.BuildpackBOMSourceMetadata)
+
+				Expect(buildpackMetadata.Launcher["version"]).To(Equal("0.6.0"))
+				Expect(buildpackMetadata.Stack).To(Equal(&metadata.BuildpackStack{
+					ID:     "io.buildpacks.stacks.bionic",
+					Mixins: []string{"build:git", "ca-certificates"},
+				}))
+				Expect(buildpackMetadata.RunImage).To(Equal(&metadata.BuildpackRunImage{
+					Image:     "cloudfoundry/run:base-cnb",
+					Mirrors:   []string{"gcr.io/cloudfoundry/run:base-cnb"},
+					Reference: "index.docker.io/cloudfoundry/run@sha256:run",
+					TopLayer:  "sha256:top",
+				}))
+
+				nodeEngine := metadata.Buildpack{ID: "org.cloudfoundry.node-engine", Version: "0.0.158"}
+				Expect(buildpackMetadata.Layers).To(Equal([]metadata.BuildpackLayer{
+					{Name: "modules", Digest: "sha256:modules", Buildpack: nodeEngine, Launch: true, Cache: true},
+					{Name: "node", Digest: "sha256:node", Buildpack: nodeEngine, Launch: true},
+				}))
+
+				Expect(buildpackMetadata.BillOfMaterials[0].Layers).To(Equal([]string{"sha256:modules", "sha256:node"}))
+				Expect(buildpackMetadata.BillOfMaterials[1].Layers).To(BeEmpty())
+			})
+
+			It("records the run image as the base of the image", func() {
+				md, _, err := Provider(test_utils.NewMockImageWithLabels(labels), common.RunParams{}, metadata.Metadata{
+					Base: metadata.Base{"name": "Ubuntu"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(md.Base).To(Equal(metadata.Base{
+					"name":             "Ubuntu",
+					"run_image":        "index.docker.io/cloudfoundry/run@sha256:run",
+					"run_image_digest": "sha256:run",
+				}))
+			})
+		})
+
+		Context("when the bill of materials has paketo metadata", func() {
+			labels := map[string]string{
+				BuildMetadataLabel: `{"bom":[{"name":"jdk","version":"11.0.14","metadata":{"uri":"https://example.com/bellsoft-jdk11.0.14.tar.gz","sha256":"abc","licenses":[{"type":"GPL-2.0 WITH Classpath-exception-2.0","uri":"https://openjdk.java.net/legal/gplv2+ce.html"}],"purl":"pkg:generic/bellsoft-jdk@11.0.14","cpes":["cpe:2.3:a:oracle:jdk:11.0.14:*:*:*:*:*:*:*"],"stacks":["io.buildpacks.stacks.bionic"],"name":"BellSoft Liberica JDK"},"buildpack":{"id":"paketo-buildpacks/bellsoft-liberica","version":"9.3.0"}},{"name":"node","version":"16.14.0","metadata":{"cpe":"cpe:2.3:a:nodejs:node.js:16.14.0:*:*:*:*:*:*:*","licenses":["MIT"],"stacks":"unexpected"},"buildpack":{"id":"paketo-buildpacks/node-engine","version":"0.12.0"}}],"buildpacks":[],"launcher":{}}`,
+			}
+
+			It("records the recognized keys as typed fields and keeps the metadata", func() {
+				md, _, err := Provider(test_utils.NewMockImageWithLabels(labels), common.RunParams{}, metadata.Metadata{})
+				Expect(err).ToNot(HaveOccurred())
+
+				boms := md.Dependencies[0].Source.Metadata.(metadata.BuildpackBOMSourceMetadata).BillOfMaterials
+				Expect(boms).To(HaveLen(2))
+
+				Expect(boms[0].Name).To(Equal("jdk"))
+				Expect(boms[0].URI).To(Equal("https://example.com/bellsoft-jdk11.0.14.tar.gz"))
+				Expect(boms[0].Sha256).To(Equal("abc"))
+				Expect(boms[0].Licenses).To(Equal([]metadata.BuildpackLicense{{
+					Type: "GPL-2.0 WITH Classpath-exception-2.0",
+					URI:  "https://openjdk.java.net/legal/gplv2+ce.html",
+				}}))
+				Expect(boms[0].PURL).To(Equal("pkg:generic/bellsoft-jdk@11.0.14"))
+				Expect(boms[0].CPEs).To(Equal([]string{"cpe:2.3:a:oracle:jdk:11.0.14:*:*:*:*:*:*:*"}))
+				Expect(boms[0].Stacks).To(Equal([]string{"io.buildpacks.stacks.bionic"}))
+				Expect(boms[0].Metadata).To(HaveKeyWithValue("uri", "https://example.com/bellsoft-jdk11.0.14.tar.gz"))
+				Expect(boms[0].Metadata).To(HaveKeyWithValue("sha256", "abc"))
+				Expect(boms[0].Metadata).To(HaveKey("licenses"))
+				Expect(boms[0].Metadata).To(HaveKeyWithValue("purl", "pkg:generic/bellsoft-jdk@11.0.14"))
+				Expect(boms[0].Metadata).To(HaveKey("cpes"))
+				Expect(boms[0].Metadata).To(HaveKey("stacks"))
+				Expect(boms[0].Metadata).To(HaveKeyWithValue("name", "BellSoft Liberica JDK"))
+
+				Expect(boms[1].Name).To(Equal("node"))
+				Expect(boms[1].CPEs).To(Equal([]string{"cpe:2.3:a:nodejs:node.js:16.14.0:*:*:*:*:*:*:*"}))
+				Expect(boms[1].Licenses).To(Equal([]metadata.BuildpackLicense{{Type: "MIT"}}))
+				Expect(boms[1].Stacks).To(BeEmpty())
+				Expect(boms[1].Metadata).To(HaveKeyWithValue("cpe", "cpe:2.3:a:nodejs:node.js:16.14.0:*:*:*:*:*:*:*"))
+				Expect(boms[1].Metadata).To(HaveKeyWithValue("stacks", "unexpected"))
+			})
+
+			It("records the digest of the metadata without the typed fields", func() {
+				md, _, err := Provider(test_utils.NewMockImageWithLabels(labels), common.RunParams{}, metadata.Metadata{})
+				Expect(err).ToNot(HaveOccurred())
+
+				untyped := md.Dependencies[0].Source.Metadata.(metadata.BuildpackBOMSourceMetadata)
+				for i, bom := range untyped.BillOfMaterials {
+					untyped.BillOfMaterials[i] = metadata.BuildpackBOM{Name: bom.Name, Version: bom.Version, Metadata: bom.Metadata, Buildpack: bom.Buildpack}
+				}
+				digest, err := common.Digest(untyped)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(md.Dependencies[0].Source.Version).To(HaveKeyWithValue("sha256", digest))
+			})
+		})
+
+		Context("when the lifecycle label is malformed", func() {
+			It("returns an error", func() {
+				_, _, err := Provider(test_utils.NewMockImageWithLabels(map[string]string{
+					LifecycleMetadataLabel: "{",
+				}), common.RunParams{}, metadata.Metadata{})
+
+				Expect(err).To(MatchError(ContainSubstring("could not parse lifecycle metadata")))
+			})
+		})
 	})
 })
@@ -0,0 +1,291 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package cnb
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// LaunchSBOMDir is where the lifecycle exports the SBOM files written by the
+// buildpacks for their launch layers, in a directory per buildpack, with the
+// files of each layer in a sub directory named after the layer.
+const LaunchSBOMDir = "/layers/sbom/launch"
+
+// sbomFormats are the SBOM files a buildpack can write, by preference, as the
+// same components are usually written in each of the formats.
+var sbomFormats = []struct {
+	fileName string
+	parse    func([]byte) ([]metadata.BuildpackComponent, error)
+}{
+	{"sbom.cdx.json", parseCycloneDX},
+	{"sbom.spdx.json", parseSPDX},
+	{"sbom.syft.json", parseSyft},
+}
+
+// sbomLocation is a directory holding the SBOM files of a buildpack, or of
+// one of its layers.
+type sbomLocation struct {
+	layer string
+	dir   string
+	files []string
+}
+
+// launchSBOMComponents reads the components of the SBOM files of the image,
+// attributed to their buildpack and layer. Files which cannot be parsed are
+// reported as warnings.
+func launchSBOMComponents(dli image.Image, buildpacks []metadata.Buildpack) ([]metadata.BuildpackComponent, []common.Warning) {
+	_, buildpackDirs, err := image.ListDir(dli, LaunchSBOMDir)
+	if err != nil {
+		// images built without SBOM layers do not have the directory
+		return nil, nil
+	}
+
+	var components []metadata.BuildpackComponent
+	var warnings []common.Warning
+	for _, buildpackDir := range buildpackDirs {
+		buildpack := findBuildpack(buildpacks, buildpackDir)
+		dir := path.Join(LaunchSBOMDir, buildpackDir)
+
+		files, layerDirs, err := image.ListDir(dli, dir)
+		if err != nil {
+			continue
+		}
+
+		locations := []sbomLocation{{dir: dir, files: files}}
+		for _, layer := range layerDirs {
+			layerFiles, _, err := image.ListDir(dli, path.Join(dir, layer))
+			if err != nil {
+				continue
+			}
+			locations = append(locations, sbomLocation{layer: layer, dir: path.Join(dir, layer), files: layerFiles})
+		}
+
+		for _, location := range locations {
+			layerComponents, err := readSBOM(dli, location.dir, location.files)
+			if err != nil {
+				warnings = append(warnings, common.Warning{
+					Code:           common.InvalidBuildpackSBOMWarning,
+					Message:        err.Error(),
+					DependencyType: metadata.BuildpackMetadataType,
+				})
+				continue
+			}
+
+			for _, component := range layerComponents {
+				component.Buildpack = buildpack
+				component.Layer = location.layer
+				components = append(components, component)
+			}
+		}
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		a, b := components[i], components[j]
+		if a.Buildpack.ID != b.Buildpack.ID {
+			return a.Buildpack.ID < b.Buildpack.ID
+		}
+		if a.Layer != b.Layer {
+			return a.Layer < b.Layer
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+
+	return components, warnings
+}
+
+// readSBOM parses the preferred SBOM file among the files of a directory.
+func readSBOM(dli image.Image, dir string, files []string) ([]metadata.BuildpackComponent, error) {
+	for _, format := range sbomFormats {
+		if !contains(files, format.fileName) {
+			continue
+		}
+
+		filePath := path.Join(dir, format.fileName)
+		content, err := dli.GetFileContent(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read buildpack sbom %s: %w", filePath, err)
+		}
+
+		components, err := format.parse([]byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse buildpack sbom %s: %w", filePath, err)
+		}
+
+		for i := range components {
+			components[i].SBOM = filePath
+		}
+		return components, nil
+	}
+
+	return nil, nil
+}
+
+// findBuildpack returns the buildpack whose id, with slashes replaced by
+// underscores, is the name of the directory.
+func findBuildpack(buildpacks []metadata.Buildpack, dirName string) metadata.Buildpack {
+	for _, buildpack := range buildpacks {
+		if strings.ReplaceAll(buildpack.ID, "/", "_") == dirName {
+			return buildpack
+		}
+	}
+	return metadata.Buildpack{ID: dirName}
+}
+
+func parseCycloneDX(content []byte) ([]metadata.BuildpackComponent, error) {
+	var document struct {
+		Components []struct {
+			Name     string `json:"name"`
+			Version  string `json:"version"`
+			PURL     string `json:"purl"`
+			Licenses []struct {
+				License struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"license"`
+				Expression string `json:"expression"`
+			} `json:"licenses"`
+			Hashes []struct {
+				Alg     string `json:"alg"`
+				Content string `json:"content"`
+			} `json:"hashes"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	var components []metadata.BuildpackComponent
+	for _, c := range document.Components {
+		component := metadata.BuildpackComponent{Name: c.Name, Version: c.Version, PURL: c.PURL}
+		for _, l := range c.Licenses {
+			component.Licenses = appendLicense(component.Licenses, l.Expression, l.License.ID, l.License.Name)
+		}
+		for _, h := range c.Hashes {
+			if strings.EqualFold(h.Alg, "SHA-256") {
+				component.Sha256 = strings.ToLower(h.Content)
+			}
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+func parseSPDX(content []byte) ([]metadata.BuildpackComponent, error) {
+	var document struct {
+		Packages []struct {
+			Name             string `json:"name"`
+			VersionInfo      string `json:"versionInfo"`
+			LicenseConcluded string `json:"licenseConcluded"`
+			LicenseDeclared  string `json:"licenseDeclared"`
+			ExternalRefs     []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+			Checksums []struct {
+				Algorithm     string `json:"algorithm"`
+				ChecksumValue string `json:"checksumValue"`
+			} `json:"checksums"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	var components []metadata.BuildpackComponent
+	for _, p := range document.Packages {
+		component := metadata.BuildpackComponent{Name: p.Name, Version: p.VersionInfo}
+		for _, ref := range p.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				component.PURL = ref.ReferenceLocator
+			}
+		}
+		component.Licenses = appendLicense(nil, spdxLicense(p.LicenseConcluded), spdxLicense(p.LicenseDeclared))
+		for _, checksum := range p.Checksums {
+			if checksum.Algorithm == "SHA256" {
+				component.Sha256 = strings.ToLower(checksum.ChecksumValue)
+			}
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+func parseSyft(content []byte) ([]metadata.BuildpackComponent, error) {
+	var document struct {
+		Artifacts []struct {
+			Name     string            `json:"name"`
+			Version  string            `json:"version"`
+			PURL     string            `json:"purl"`
+			Licenses []json.RawMessage `json:"licenses"`
+		} `json:"artifacts"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	var components []metadata.BuildpackComponent
+	for _, a := range document.Artifacts {
+		component := metadata.BuildpackComponent{Name: a.Name, Version: a.Version, PURL: a.PURL}
+		for _, raw := range a.Licenses {
+			// licenses are strings in older syft documents, objects in newer
+			// ones
+			var license string
+			if err := json.Unmarshal(raw, &license); err != nil {
+				var object struct {
+					Value          string `json:"value"`
+					SPDXExpression string `json:"spdxExpression"`
+				}
+				if err := json.Unmarshal(raw, &object); err != nil {
+					return nil, err
+				}
+				component.Licenses = appendLicense(component.Licenses, object.SPDXExpression, object.Value)
+				continue
+			}
+			component.Licenses = appendLicense(component.Licenses, license)
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+// appendLicense appends the first non empty license, if not already present.
+func appendLicense(licenses []string, candidates ...string) []string {
+	for _, license := range candidates {
+		if license == "" {
+			continue
+		}
+		if !contains(licenses, license) {
+			licenses = append(licenses, license)
+		}
+		break
+	}
+	return licenses
+}
+
+func spdxLicense(license string) string {
+	if license == "NOASSERTION" || license == "NONE" {
+		return ""
+	}
+	return license
+}
+
+func contains(a []string, x string) bool {
+	for _, n := range a {
+		if x == n {
+			return true
+		}
+	}
+	return false
+}
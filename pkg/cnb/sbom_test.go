@@ -0,0 +1,164 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package cnb_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/cnb"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// MockImage is an image with the given labels whose rootfs holds the given
+// files, by path.
+type MockImage struct {
+	labels map[string]string
+	files  map[string]string
+}
+
+func (m MockImage) GetConfig() (*v1.ConfigFile, error) {
+	config := &v1.ConfigFile{}
+	config.Config.Labels = m.labels
+	return config, nil
+}
+
+func (m MockImage) GetFileContent(filePath string) (string, error) {
+	content, ok := m.files[filePath]
+	if !ok {
+		return "", fmt.Errorf("could not find file in rootFS: %s", filePath)
+	}
+	return content, nil
+}
+
+func (m MockImage) GetDirFileNames(dir string, includeDir bool) ([]string, error) {
+	found := false
+	names := map[string]bool{}
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	for filePath := range m.files {
+		if !strings.HasPrefix(filePath, prefix) {
+			continue
+		}
+		found = true
+		rest := strings.TrimPrefix(filePath, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			if includeDir {
+				names[rest[:i]] = true
+			}
+			continue
+		}
+		names[rest] = true
+	}
+	if !found {
+		return nil, fmt.Errorf("could not find directory in rootFS: %s", dir)
+	}
+
+	var fileNames []string
+	for name := range names {
+		fileNames = append(fileNames, name)
+	}
+	sort.Strings(fileNames)
+	return fileNames, nil
+}
+
+func (m MockImage) GetDirContents(string) ([]string, error) {
+	panic("implement me")
+}
+
+func (m MockImage) AbsolutePath(string) (string, error) {
+	panic("implement me")
+}
+
+func (m MockImage) ExportWithMetadata(metadata.Metadata, string, string) error {
+	panic("implement me")
+}
+
+var _ = Describe("buildpack sbom layers", func() {
+	labels := map[string]string{
+		BuildMetadataLabel: `{"bom":[],"buildpacks":[{"id":"paketo-buildpacks/node-engine","version":"1.2.3"},{"id":"paketo-buildpacks/npm-install","version":"0.4.5"}],"launcher":{"version":"0.13.0"}}`,
+	}
+
+	const cycloneDX = `{"bomFormat":"CycloneDX","components":[{"type":"library","name":"node","version":"16.14.0","purl":"pkg:generic/node@16.14.0","licenses":[{"license":{"id":"MIT"}}],"hashes":[{"alg":"SHA-256","content":"ABC"}]}]}`
+	const spdx = `{"spdxVersion":"SPDX-2.2","packages":[{"name":"node","versionInfo":"16.14.0","licenseConcluded":"NOASSERTION","licenseDeclared":"MIT"}]}`
+	const syft = `{"artifacts":[{"name":"express","version":"4.17.3","purl":"pkg:npm/express@4.17.3","licenses":["MIT"]},{"name":"debug","version":"2.6.9","purl":"pkg:npm/debug@2.6.9","licenses":[{"value":"MIT","spdxExpression":"MIT"}]}]}`
+
+	It("records the components of each layer, attributed to their buildpack and layer", func() {
+		dli := MockImage{labels: labels, files: map[string]string{
+			"/layers/sbom/launch/paketo-buildpacks_node-engine/node/sbom.cdx.json":     cycloneDX,
+			"/layers/sbom/launch/paketo-buildpacks_node-engine/node/sbom.spdx.json":    spdx,
+			"/layers/sbom/launch/paketo-buildpacks_npm-install/modules/sbom.syft.json": syft,
+			"/layers/sbom/launch/paketo-buildpacks_npm-install/sbom.spdx.json":         `{"packages":[{"name":"app","versionInfo":"1.0.0","externalRefs":[{"referenceType":"purl","referenceLocator":"pkg:npm/app@1.0.0"}]}]}`,
+		}}
+
+		md, warnings, err := Provider(dli, common.RunParams{}, metadata.Metadata{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+
+		nodeEngine := metadata.Buildpack{ID: "paketo-buildpacks/node-engine", Version: "1.2.3"}
+		npmInstall := metadata.Buildpack{ID: "paketo-buildpacks/npm-install", Version: "0.4.5"}
+		Expect(md.Dependencies[0].Source.Metadata.(metadata.BuildpackBOMSourceMetadata).Components).To(Equal([]metadata.BuildpackComponent{
+			{
+				Name: "node", Version: "16.14.0", PURL: "pkg:generic/node@16.14.0", Licenses: []string{"MIT"}, Sha256: "abc",
+				Buildpack: nodeEngine, Layer: "node", SBOM: "/layers/sbom/launch/paketo-buildpacks_node-engine/node/sbom.cdx.json",
+			},
+			{
+				Name: "app", Version: "1.0.0", PURL: "pkg:npm/app@1.0.0",
+				Buildpack: npmInstall, SBOM: "/layers/sbom/launch/paketo-buildpacks_npm-install/sbom.spdx.json",
+			},
+			{
+				Name: "debug", Version: "2.6.9", PURL: "pkg:npm/debug@2.6.9", Licenses: []string{"MIT"},
+				Buildpack: npmInstall, Layer: "modules", SBOM: "/layers/sbom/launch/paketo-buildpacks_npm-install/modules/sbom.syft.json",
+			},
+			{
+				Name: "express", Version: "4.17.3", PURL: "pkg:npm/express@4.17.3", Licenses: []string{"MIT"},
+				Buildpack: npmInstall, Layer: "modules", SBOM: "/layers/sbom/launch/paketo-buildpacks_npm-install/modules/sbom.syft.json",
+			},
+		}))
+	})
+
+	It("uses the declared license of spdx packages without a concluded license", func() {
+		dli := MockImage{labels: labels, files: map[string]string{
+			"/layers/sbom/launch/paketo-buildpacks_node-engine/node/sbom.spdx.json": spdx,
+		}}
+
+		md, _, err := Provider(dli, common.RunParams{}, metadata.Metadata{})
+		Expect(err).ToNot(HaveOccurred())
+
+		components := md.Dependencies[0].Source.Metadata.(metadata.BuildpackBOMSourceMetadata).Components
+		Expect(components).To(HaveLen(1))
+		Expect(components[0].Licenses).To(Equal([]string{"MIT"}))
+	})
+
+	It("reports sbom files which cannot be parsed as warnings", func() {
+		dli := MockImage{labels: labels, files: map[string]string{
+			"/layers/sbom/launch/paketo-buildpacks_node-engine/node/sbom.cdx.json":     "{",
+			"/layers/sbom/launch/paketo-buildpacks_npm-install/modules/sbom.syft.json": syft,
+		}}
+
+		md, warnings, err := Provider(dli, common.RunParams{}, metadata.Metadata{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(warnings).To(HaveLen(1))
+		Expect(warnings[0].Code).To(Equal(common.InvalidBuildpackSBOMWarning))
+		Expect(warnings[0].Message).To(ContainSubstring("/layers/sbom/launch/paketo-buildpacks_node-engine/node/sbom.cdx.json"))
+		Expect(md.Dependencies[0].Source.Metadata.(metadata.BuildpackBOMSourceMetadata).Components).To(HaveLen(2))
+	})
+
+	It("records the components of an image without buildpack labels", func() {
+		dli := MockImage{files: map[string]string{
+			"/layers/sbom/launch/example_buildpack/layer/sbom.syft.json": syft,
+		}}
+
+		md, _, err := Provider(dli, common.RunParams{}, metadata.Metadata{})
+		Expect(err).ToNot(HaveOccurred())
+
+		components := md.Dependencies[0].Source.Metadata.(metadata.BuildpackBOMSourceMetadata).Components
+		Expect(components[0].Buildpack).To(Equal(metadata.Buildpack{ID: "example_buildpack"}))
+	})
+})
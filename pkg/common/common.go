@@ -8,19 +8,64 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 type RunParams struct {
 	InputImageTarPath         string
 	InputImage                string
 	GitPaths                  []string
+	GitDirty                  string
+	GitRemote                 string
 	Tag                       string
 	OutputImageTar            string
 	MetadataFilePath          string
 	DpkgFilePath              string
 	AdditionalSourceUrls      []string
 	AdditionalSourceFilePaths []string
+	BaseImages                []string
+	BaseImageCatalogPaths     []string
+	EOLDataPath               string
+	FailOnEOL                 bool
+	MergePrecedence           string
 	IgnoreValidationErrors    bool
+	ArchiveCacheDir           string
+	Offline                   bool
+	AllowedHosts              []string
+	Proxy                     string
+	URLCacheDir               string
+	URLCacheTTL               time.Duration
+	URLRetries                int
+	Providers                 []string
+	SkipProviders             []string
+	ProviderOptions           ProviderOptions
+	WarningsFilePath          string
+	FailOnWarning             []string
+	Flags                     map[string][]string
+	ExtendedProvenance        bool
+	ProvenanceFilePath        string
+	SBOMReferrer              string
+	SBOMFormat                string
+	LabelFormat               string
+	LabelPartSize             int
+	SignKeyPath               string
+	SignTarget                string
+	Signer                    string
+	SignatureFilePath         string
+	SignatureReferrer         string
+}
+
+type ProviderOptions struct {
+	Dpkg DpkgOptions `yaml:"dpkg"`
+	RPM  RPMOptions  `yaml:"rpm"`
+}
+
+type DpkgOptions struct {
+	StatusPaths []string `yaml:"status_paths"`
+}
+
+type RPMOptions struct {
+	DbPath string `yaml:"db_path"`
 }
 
 func Digest(sourceMetadata interface{}) (string, error) {
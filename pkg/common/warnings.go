@@ -0,0 +1,33 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package common
+
+import "fmt"
+
+const (
+	InvalidSourceURLWarning       = "invalid-source-url"
+	InvalidSourcesFileWarning     = "invalid-sources-file"
+	MetadataMismatchWarning       = "metadata-mismatch"
+	DirtyGitTreeWarning           = "dirty-git-tree"
+	ArchiveDigestWarning          = "archive-digest-mismatch"
+	MissingSourceFileWarning      = "missing-source-file"
+	UnsupportedKpackSourceWarning = "unsupported-kpack-source"
+	InvalidBuildpackSBOMWarning   = "invalid-buildpack-sbom"
+	EndOfLifeBaseWarning          = "end-of-life-base"
+)
+
+// Warning is a problem found by a provider which did not stop it from
+// generating metadata.
+type Warning struct {
+	Code           string `json:"code"`
+	Message        string `json:"message"`
+	DependencyType string `json:"dependency_type,omitempty"`
+}
+
+func (w Warning) String() string {
+	if w.DependencyType == "" {
+		return fmt.Sprintf("[%s] %s", w.Code, w.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", w.Code, w.DependencyType, w.Message)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+
+	"gopkg.in/yaml.v2"
+)
+
+const DefaultConfigFileName = "deplab.yaml"
+
+type Config struct {
+	Providers       []string               `yaml:"providers"`
+	SkipProviders   []string               `yaml:"skip_providers"`
+	ProviderOptions common.ProviderOptions `yaml:"provider_options"`
+}
+
+func ParseConfigFile(configFilePath string) (Config, error) {
+	configFileReader, err := os.Open(configFilePath)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not open config file %s: %w", configFilePath, err)
+	}
+	defer configFileReader.Close()
+
+	decoder := yaml.NewDecoder(configFileReader)
+	decoder.SetStrict(true)
+
+	var config Config
+	err = decoder.Decode(&config)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not parse config file %s: %w", configFilePath, err)
+	}
+
+	return config, nil
+}
+
+// Apply overlays the config file onto the run params. Providers given on the
+// command line take precedence over the config file, skipped providers from
+// both sources are combined.
+func (c Config) Apply(params common.RunParams) common.RunParams {
+	if len(params.Providers) == 0 {
+		params.Providers = c.Providers
+	}
+	params.SkipProviders = append(append([]string{}, c.SkipProviders...), params.SkipProviders...)
+	params.ProviderOptions = c.ProviderOptions
+
+	return params
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/config"
+)
+
+var _ = Describe("Config", func() {
+	var configFilePath string
+
+	writeConfigFile := func(content string) {
+		f, err := ioutil.TempFile("", "deplab-config")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.WriteString(content)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+		configFilePath = f.Name()
+	}
+
+	AfterEach(func() {
+		os.Remove(configFilePath)
+	})
+
+	Describe("ParseConfigFile", func() {
+		It("parses providers, skipped providers and provider options", func() {
+			writeConfigFile(`providers:
+- dpkg
+- rpm
+skip_providers:
+- os-release
+provider_options:
+  dpkg:
+    status_paths:
+    - /var/lib/dpkg/status-old
+  rpm:
+    db_path: /usr/lib/sysimage/rpm
+`)
+
+			config, err := ParseConfigFile(configFilePath)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config).To(Equal(Config{
+				Providers:     []string{"dpkg", "rpm"},
+				SkipProviders: []string{"os-release"},
+				ProviderOptions: common.ProviderOptions{
+					Dpkg: common.DpkgOptions{StatusPaths: []string{"/var/lib/dpkg/status-old"}},
+					RPM:  common.RPMOptions{DbPath: "/usr/lib/sysimage/rpm"},
+				},
+			}))
+		})
+
+		It("returns an error for unknown keys", func() {
+			writeConfigFile(`provider:
+- dpkg
+`)
+
+			_, err := ParseConfigFile(configFilePath)
+			Expect(err).To(MatchError(ContainSubstring("could not parse config file")))
+		})
+
+		It("returns an error if the file does not exist", func() {
+			configFilePath = "/this/does/not/exist.yaml"
+
+			_, err := ParseConfigFile(configFilePath)
+			Expect(err).To(MatchError(ContainSubstring("could not open config file")))
+		})
+	})
+
+	Describe("Apply", func() {
+		It("prefers providers set on the run params and combines skipped providers", func() {
+			params := Config{
+				Providers:     []string{"dpkg"},
+				SkipProviders: []string{"os-release"},
+			}.Apply(common.RunParams{
+				Providers:     []string{"rpm"},
+				SkipProviders: []string{"cnb"},
+			})
+
+			Expect(params.Providers).To(Equal([]string{"rpm"}))
+			Expect(params.SkipProviders).To(ConsistOf("os-release", "cnb"))
+		})
+
+		It("does not modify the skipped providers of the config", func() {
+			skipProviders := make([]string, 1, 2)
+			skipProviders[0] = "os-release"
+			config := Config{SkipProviders: skipProviders}
+
+			config.Apply(common.RunParams{SkipProviders: []string{"cnb"}})
+			config.Apply(common.RunParams{SkipProviders: []string{"rpm"}})
+
+			Expect(skipProviders[:2]).To(Equal([]string{"os-release", ""}))
+		})
+
+		It("uses the providers from the config file when none are set on the run params", func() {
+			params := Config{Providers: []string{"dpkg"}}.Apply(common.RunParams{})
+
+			Expect(params.Providers).To(Equal([]string{"dpkg"}))
+		})
+	})
+})
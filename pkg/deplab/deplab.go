@@ -7,29 +7,20 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"github.com/vmware-tanzu/dependency-labeler/pkg/kpack"
 	"os"
-	"strings"
-
-	"github.com/vmware-tanzu/dependency-labeler/pkg/cnb"
+	"time"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/additionalsources"
-	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
-	"github.com/vmware-tanzu/dependency-labeler/pkg/rpm"
 
-	"github.com/vmware-tanzu/dependency-labeler/pkg/git"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/dpkg"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
 
-	"github.com/vmware-tanzu/dependency-labeler/pkg/osrelease"
-
 	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
 )
 
-type provider func(image.Image, common.RunParams, metadata.Metadata) (metadata.Metadata, error)
-
 var Version = "0.0.0-dev"
 var Provenance = metadata.Provenance{
 	Name:    "deplab",
@@ -38,6 +29,11 @@ var Provenance = metadata.Provenance{
 }
 
 func Run(params common.RunParams) error {
+	providers, err := SelectProviders(LabelProviders, params.Providers, params.SkipProviders)
+	if err != nil {
+		return fmt.Errorf("could not select providers: %w", err)
+	}
+
 	dli, err := image.NewDeplabImage(params.InputImage, params.InputImageTarPath)
 
 	if err != nil {
@@ -47,54 +43,106 @@ func Run(params common.RunParams) error {
 
 	md := metadata.Metadata{Dependencies: make([]metadata.Dependency, 0)}
 
-	for _, provider := range []provider{
-		dpkg.Provider,
-		rpm.Provider,
-		cnb.Provider,
-		git.Provider,
-		additionalsources.ArchiveUrlProvider,
-		additionalsources.AdditionalSourcesProvider,
-		osrelease.Provider,
-		ProvenanceProvider,
-	} {
-		if md2, err := provider(&dli, params, md); err == nil {
-			md = md2
-		} else {
-			return fmt.Errorf("error generating dependencies: %w", err)
+	startedOn := time.Now()
+	timings := NewProviderTimings()
+
+	md, warnings, err := RunProviders(&dli, params, md, timings.Timed(providers))
+	if err != nil {
+		return fmt.Errorf("error generating dependencies: %w", err)
+	}
+
+	var build metadata.BuildProvenance
+	if params.ExtendedProvenance || params.ProvenanceFilePath != "" {
+		inputImageDigest, err := dli.Digest()
+		if err != nil {
+			return fmt.Errorf("could not retrieve input image digest: %w", err)
+		}
+		build = NewBuildProvenance(params, inputImageDigest.String(), timings.Runs(providers), startedOn, time.Now())
+	}
+
+	if params.ExtendedProvenance {
+		md = addBuildProvenance(md, build)
+	}
+
+	PrintWarnings(os.Stderr, warnings)
+
+	if params.WarningsFilePath != "" {
+		err = WriteWarningsFile(warnings, params.WarningsFilePath)
+		if err != nil {
+			return fmt.Errorf("could not write warnings file: %w", err)
+		}
+	}
+
+	err = CheckFailOnWarning(warnings, params.FailOnWarning)
+	if err != nil {
+		return err
+	}
+
+	err = CheckFailOnEOL(warnings, params.FailOnEOL)
+	if err != nil {
+		return err
+	}
+
+	// the sbom is attached first, as the label may reference it
+	labelOptions := metadata.LabelOptions{Format: params.LabelFormat, PartSize: params.LabelPartSize}
+	if params.SBOMReferrer != "" {
+		labelOptions.Reference, err = writeSBOMReferrer(dli, params, md)
+		if err != nil {
+			return fmt.Errorf("could not attach sbom referrer: %w", err)
 		}
 	}
+	dli.SetLabelOptions(labelOptions)
 
 	err = writeOutputs(dli, params, md)
 	if err != nil {
 		return fmt.Errorf("could not write outputs: %w", err)
 	}
 
+	if params.ProvenanceFilePath != "" {
+		err = writeProvenanceStatement(dli, params, md, build)
+		if err != nil {
+			return fmt.Errorf("could not write provenance statement file: %w", err)
+		}
+	}
+
+	if params.SignKeyPath != "" {
+		err = writeSignature(dli, params, md)
+		if err != nil {
+			return fmt.Errorf("could not sign %s: %w", params.SignTarget, err)
+		}
+	}
+
 	return nil
 }
 
-func RunInspect(inputImage, inputImageTar string) error {
+// RunInspect prints the metadata of the image given with params.InputImage or
+// params.InputImageTarPath. Only the eol and merge options of params apply to
+// the providers.
+func RunInspect(params common.RunParams) error {
+	inputImage, inputImageTar := params.InputImage, params.InputImageTarPath
 	dli, err := image.NewDeplabImage(inputImage, inputImageTar)
 
 	if err != nil {
 		return fmt.Errorf("inspect cannot open the provided image from '%s%s': %s", inputImage, inputImageTar, err)
 	}
 
+	if params.SBOMReferrer != "" {
+		return inspectSBOMReferrers(dli, params.SBOMReferrer)
+	}
+
 	inspectMetadata := metadata.Metadata{}
 
-	for _, provider := range []provider{
-		dpkg.Provider,
-		rpm.Provider,
-		cnb.Provider,
-		osrelease.Provider,
-		kpack.Provider,
-		ProvenanceProvider,
-		ExistingLabelProvider,
-	} {
-		if md2, err := provider(&dli, common.RunParams{}, inspectMetadata); err == nil {
-			inspectMetadata = md2
-		} else {
-			return fmt.Errorf("inspect error generating dependencies for image '%s%s': %w", inputImageTar, inputImage, err)
-		}
+	providerParams := common.RunParams{EOLDataPath: params.EOLDataPath, MergePrecedence: params.MergePrecedence}
+	inspectMetadata, warnings, err := RunProviders(&dli, providerParams, inspectMetadata, InspectProviders)
+	if err != nil {
+		return fmt.Errorf("inspect error generating dependencies for image '%s%s': %w", inputImageTar, inputImage, err)
+	}
+
+	PrintWarnings(os.Stderr, warnings)
+
+	err = CheckFailOnEOL(warnings, params.FailOnEOL)
+	if err != nil {
+		return err
 	}
 
 	label, err := json.Marshal(inspectMetadata)
@@ -138,42 +186,102 @@ func writeOutputs(dli image.Image, params common.RunParams, md metadata.Metadata
 	return nil
 }
 
-func ProvenanceProvider(_ image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
+func ProvenanceProvider(_ image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
 	md.Provenance = append(md.Provenance, Provenance)
-	return md, nil
+	return md, nil, nil
 }
 
-func ExistingLabelProvider(dli image.Image, _ common.RunParams, md metadata.Metadata) (m metadata.Metadata, err error) {
+func ExistingLabelProvider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
 	cf, err := dli.GetConfig()
 	if err != nil {
-		return metadata.Metadata{}, fmt.Errorf("cannot retrieve the Config file: %w", err)
+		return metadata.Metadata{}, nil, fmt.Errorf("cannot retrieve the Config file: %w", err)
 	}
 
 	existingMetadata := metadata.Metadata{}
-	existinglabel, foundDeplab := cf.Config.Labels["io.deplab.metadata"]
+	existinglabel, foundDeplab, err := metadata.DecodeLabels(cf.Config.Labels, resolveLabelReference)
+	if err != nil {
+		return metadata.Metadata{}, nil, fmt.Errorf("cannot read the label %s: %w", metadata.Label, err)
+	}
 
 	if foundDeplab {
-		var err = json.Unmarshal([]byte(existinglabel), &existingMetadata)
+		var err = json.Unmarshal(existinglabel, &existingMetadata)
 		if err != nil {
-			return metadata.Metadata{}, fmt.Errorf("cannot parse the label %s: %w", existinglabel, err)
+			return metadata.Metadata{}, nil, fmt.Errorf("cannot parse the label %s: %w", existinglabel, err)
 		}
 	} else {
-		if existinglabel, ok := cf.Config.Labels["io.pivotal.metadata"]; ok {
+		if existinglabel, ok := cf.Config.Labels[metadata.LegacyLabel]; ok {
 			var err = json.Unmarshal([]byte(existinglabel), &existingMetadata)
 			if err != nil {
-				return metadata.Metadata{}, fmt.Errorf("cannot parse the label %s: %w", existinglabel, err)
+				return metadata.Metadata{}, nil, fmt.Errorf("cannot parse the label %s: %w", existinglabel, err)
 			}
 		}
 	}
 
-	mergedMetadata, warnings := metadata.Merge(existingMetadata, md)
-	if len(warnings) > 0 {
-		var warnStrings []string
-		for _, warning := range warnings {
-			warnStrings = append(warnStrings, string(warning))
+	precedence := metadata.Precedence(params.MergePrecedence)
+	if precedence == "" {
+		precedence = metadata.PreferCurrent
+	}
+
+	mergedMetadata, mergeWarnings, conflicts, err := metadata.MergeWithPrecedence(existingMetadata, md, precedence)
+	if err != nil {
+		return metadata.Metadata{}, nil, fmt.Errorf("the label already present on image conflicts with the image: %w", err)
+	}
+
+	conflictingTypes := map[string]bool{}
+	for _, conflict := range conflicts {
+		conflictingTypes[conflict.DependencyType] = true
+	}
+
+	// the differences of types with conflicts are reported per conflict
+	var warnings []common.Warning
+	for _, mergeWarning := range mergeWarnings {
+		if conflictingTypes[string(mergeWarning)] {
+			continue
 		}
-		fmt.Fprintln(os.Stderr, "Difference identified in matching metadata elements already present on image:", strings.Join(warnStrings, ", "))
+		warnings = append(warnings, common.Warning{
+			Code:           common.MetadataMismatchWarning,
+			Message:        "difference identified in matching metadata elements already present on image",
+			DependencyType: string(mergeWarning),
+		})
+	}
+	for _, conflict := range conflicts {
+		warnings = append(warnings, common.Warning{
+			Code:           common.MetadataMismatchWarning,
+			Message:        fmt.Sprintf("%s is %s in the label already present on image and %s in the image", conflict.Name, orNone(conflict.Original), orNone(conflict.Current)),
+			DependencyType: conflict.DependencyType,
+		})
 	}
 
-	return mergedMetadata, nil
+	return mergedMetadata, warnings, nil
+}
+
+func orNone(version string) string {
+	if version == "" {
+		return "missing"
+	}
+	return version
+}
+
+func RunValidateSources(additionalSourcesFilePaths []string, params common.RunParams) error {
+	validator, err := additionalsources.NewURLValidator(params)
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for _, path := range additionalSourcesFilePaths {
+		err := additionalsources.ValidateAdditionalSourcesFile(path, validator)
+		if err != nil {
+			fmt.Printf("%s: %s\n", path, err)
+			invalid++
+			continue
+		}
+		fmt.Printf("%s: valid\n", path)
+	}
+
+	if invalid != 0 {
+		return fmt.Errorf("%d of %d additional sources file(s) are invalid", invalid, len(additionalSourcesFilePaths))
+	}
+
+	return nil
 }
@@ -0,0 +1,56 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/deplab"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/test/test_utils"
+)
+
+var _ = Describe("ExistingLabelProvider", func() {
+	archive := func(sha256 string) metadata.Dependency {
+		return metadata.Dependency{
+			Type: metadata.PackageType,
+			Source: metadata.Source{
+				Type:     metadata.ArchiveType,
+				Metadata: metadata.ArchiveSourceMetadata{URL: "https://example.com/source.tar.gz", Sha256: sha256},
+			},
+		}
+	}
+
+	It("reports each conflict once, and the other differences per type", func() {
+		label, err := json.Marshal(metadata.Metadata{
+			Base:         metadata.Base{"name": "Ubuntu"},
+			Dependencies: []metadata.Dependency{archive("original")},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		image := test_utils.NewMockImageWithLabels(map[string]string{metadata.Label: string(label)})
+
+		md, warnings, err := ExistingLabelProvider(image, common.RunParams{}, metadata.Metadata{
+			Base:         metadata.Base{"name": "Debian"},
+			Dependencies: []metadata.Dependency{archive("current")},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(md.Dependencies).To(HaveLen(1))
+		Expect(warnings).To(ConsistOf(
+			common.Warning{
+				Code:           common.MetadataMismatchWarning,
+				Message:        "difference identified in matching metadata elements already present on image",
+				DependencyType: "base",
+			},
+			common.Warning{
+				Code:           common.MetadataMismatchWarning,
+				Message:        "https://example.com/source.tar.gz is original in the label already present on image and current in the image",
+				DependencyType: metadata.ArchiveType,
+			},
+		))
+	})
+})
@@ -0,0 +1,122 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/provenance"
+)
+
+// ProviderTimings records how long each provider took to run.
+type ProviderTimings struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+}
+
+func NewProviderTimings() *ProviderTimings {
+	return &ProviderTimings{durations: map[string]time.Duration{}}
+}
+
+// Timed wraps the providers so that their run time is recorded.
+func (t *ProviderTimings) Timed(providers []Provider) []Provider {
+	var timed []Provider
+	for _, provider := range providers {
+		provider := provider
+		run := provider.Run
+		provider.Run = func(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+			start := time.Now()
+			defer t.record(provider.Name, start)
+			return run(dli, params, md)
+		}
+		timed = append(timed, provider)
+	}
+	return timed
+}
+
+func (t *ProviderTimings) record(name string, start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.durations[name] = time.Since(start)
+}
+
+// Runs returns the recorded run times, in the order of the providers.
+func (t *ProviderTimings) Runs(providers []Provider) []metadata.ProviderRun {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	runs := []metadata.ProviderRun{}
+	for _, provider := range providers {
+		if duration, ok := t.durations[provider.Name]; ok {
+			runs = append(runs, metadata.ProviderRun{
+				Name:       provider.Name,
+				DurationMs: duration.Milliseconds(),
+			})
+		}
+	}
+	return runs
+}
+
+func NewBuildProvenance(params common.RunParams, inputImageDigest string, providerRuns []metadata.ProviderRun, startedOn, finishedOn time.Time) metadata.BuildProvenance {
+	inputImageReference := params.InputImage
+	if inputImageReference == "" {
+		inputImageReference = params.InputImageTarPath
+	}
+
+	return metadata.BuildProvenance{
+		StartedOn:  startedOn.UTC().Format(time.RFC3339),
+		FinishedOn: finishedOn.UTC().Format(time.RFC3339),
+		InputImage: metadata.ProvenanceImage{
+			Reference: common.RedactURL(inputImageReference),
+			Digest:    inputImageDigest,
+		},
+		Flags:            redactFlags(params.Flags),
+		Providers:        providerRuns,
+		HostOS:           runtime.GOOS,
+		HostArchitecture: runtime.GOARCH,
+	}
+}
+
+func redactFlags(flags map[string][]string) map[string][]string {
+	redacted := map[string][]string{}
+	for name, values := range flags {
+		redacted[name] = common.RedactFlag(name, values)
+	}
+	return redacted
+}
+
+// addBuildProvenance attaches the build provenance to the provenance entry
+// added by this run of deplab.
+func addBuildProvenance(md metadata.Metadata, build metadata.BuildProvenance) metadata.Metadata {
+	for i := len(md.Provenance) - 1; i >= 0; i-- {
+		if md.Provenance[i].Name == Provenance.Name {
+			md.Provenance[i].Build = &build
+			break
+		}
+	}
+	return md
+}
+
+func writeProvenanceStatement(dli image.RootFSImage, params common.RunParams, md metadata.Metadata, build metadata.BuildProvenance) error {
+	outputDigest, err := dli.DigestWithMetadata(md)
+	if err != nil {
+		return fmt.Errorf("could not compute digest of the labeled image: %w", err)
+	}
+
+	outputName := params.Tag
+	if outputName == "" {
+		outputName = build.InputImage.Reference
+	}
+
+	tool := Provenance
+	tool.Build = &build
+
+	return provenance.WriteStatementFile(provenance.NewStatement(tool, outputName, outputDigest.String()), params.ProvenanceFilePath)
+}
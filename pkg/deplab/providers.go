@@ -0,0 +1,187 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/additionalsources"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/baseimage"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/cnb"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/dpkg"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/eol"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/git"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/kpack"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/osrelease"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/rpm"
+)
+
+type ProviderFunc func(image.Image, common.RunParams, metadata.Metadata) (metadata.Metadata, []common.Warning, error)
+
+type Provider struct {
+	Name string
+	Run  ProviderFunc
+	// Concurrent providers only read the image and do not depend on the
+	// metadata generated by other providers, so they can run in parallel.
+	Concurrent bool
+	// After names the providers whose metadata the provider reads, so that
+	// it runs after them when they are selected, whatever the selected order.
+	After []string
+	// Last providers always run after all the other providers, whatever the
+	// selected order.
+	Last bool
+}
+
+const (
+	DpkgProviderName                  = "dpkg"
+	RPMProviderName                   = "rpm"
+	CNBProviderName                   = "cnb"
+	KpackProviderName                 = "kpack"
+	GitProviderName                   = "git"
+	ArchiveUrlProviderName            = "additional-source-url"
+	AdditionalSourcesFileProviderName = "additional-sources-file"
+	OSReleaseProviderName             = "os-release"
+	BaseImageProviderName             = "base-image"
+	EOLProviderName                   = "eol"
+	ProvenanceProviderName            = "provenance"
+	ExistingLabelProviderName         = "existing-label"
+)
+
+// LabelProviders are the providers run when labeling an image, in their
+// default order.
+var LabelProviders = []Provider{
+	{Name: DpkgProviderName, Run: dpkg.Provider, Concurrent: true},
+	{Name: RPMProviderName, Run: rpm.Provider, Concurrent: true},
+	{Name: CNBProviderName, Run: cnb.Provider, Concurrent: true},
+	{Name: GitProviderName, Run: git.Provider, Concurrent: true},
+	{Name: ArchiveUrlProviderName, Run: additionalsources.ArchiveUrlProvider, Concurrent: true},
+	{Name: AdditionalSourcesFileProviderName, Run: additionalsources.AdditionalSourcesProvider, Concurrent: true},
+	// kpack reads the sources recorded so far, so that the source given with
+	// --git is not recorded twice
+	{Name: KpackProviderName, Run: kpack.Provider, After: []string{GitProviderName, ArchiveUrlProviderName, AdditionalSourcesFileProviderName}},
+	{Name: OSReleaseProviderName, Run: osrelease.Provider, Concurrent: true},
+	{Name: BaseImageProviderName, Run: baseimage.Provider, Concurrent: true},
+	// eol reads the base detected by os-release
+	{Name: EOLProviderName, Run: eol.Provider, After: []string{OSReleaseProviderName}},
+	{Name: ProvenanceProviderName, Run: ProvenanceProvider, Last: true},
+}
+
+// InspectProviders are the providers run when inspecting an image, in their
+// default order.
+var InspectProviders = []Provider{
+	{Name: DpkgProviderName, Run: dpkg.Provider, Concurrent: true},
+	{Name: RPMProviderName, Run: rpm.Provider, Concurrent: true},
+	{Name: CNBProviderName, Run: cnb.Provider, Concurrent: true},
+	{Name: OSReleaseProviderName, Run: osrelease.Provider, Concurrent: true},
+	{Name: BaseImageProviderName, Run: baseimage.Provider, Concurrent: true},
+	{Name: EOLProviderName, Run: eol.Provider, After: []string{OSReleaseProviderName}},
+	{Name: KpackProviderName, Run: kpack.Provider, Concurrent: true},
+	{Name: ProvenanceProviderName, Run: ProvenanceProvider, Last: true},
+	{Name: ExistingLabelProviderName, Run: ExistingLabelProvider, Last: true},
+}
+
+// SelectProviders returns the providers named in selected, in that order, or
+// all the available providers when selected is empty. Providers named in
+// skipped are left out, providers are moved after the selected providers
+// they run after, and providers which must run last are moved to the end.
+func SelectProviders(available []Provider, selected, skipped []string) ([]Provider, error) {
+	var unknown []string
+
+	for _, name := range append(append([]string{}, selected...), skipped...) {
+		if _, ok := findProvider(available, name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) != 0 {
+		return nil, fmt.Errorf("unknown provider(s) %s, available providers are: %s",
+			strings.Join(unknown, ", "), strings.Join(ProviderNames(available), ", "))
+	}
+
+	if len(selected) == 0 {
+		selected = ProviderNames(available)
+	}
+
+	var providers []Provider
+	for _, name := range selected {
+		if contains(skipped, name) || contains(ProviderNames(providers), name) {
+			continue
+		}
+
+		provider, _ := findProvider(available, name)
+		providers = append(providers, provider)
+	}
+
+	return orderProviders(providers)
+}
+
+// orderProviders keeps the order of the providers, except that each one is
+// delayed until the providers it runs after, and, for the last ones, all the
+// other providers, have run.
+func orderProviders(providers []Provider) ([]Provider, error) {
+	var ordered []Provider
+	pending := providers
+
+	isReady := func(provider Provider) bool {
+		for _, other := range pending {
+			if other.Name == provider.Name {
+				continue
+			}
+			if contains(provider.After, other.Name) || (provider.Last && !other.Last) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for len(pending) > 0 {
+		next := -1
+		for i, provider := range pending {
+			if isReady(provider) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("providers %s depend on each other", strings.Join(ProviderNames(pending), ", "))
+		}
+
+		ordered = append(ordered, pending[next])
+		remaining := make([]Provider, 0, len(pending)-1)
+		remaining = append(remaining, pending[:next]...)
+		pending = append(remaining, pending[next+1:]...)
+	}
+
+	return ordered, nil
+}
+
+func ProviderNames(providers []Provider) []string {
+	var names []string
+	for _, provider := range providers {
+		names = append(names, provider.Name)
+	}
+	return names
+}
+
+func findProvider(providers []Provider, name string) (Provider, bool) {
+	for _, provider := range providers {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+	return Provider{}, false
+}
+
+func contains(a []string, x string) bool {
+	for _, n := range a {
+		if x == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/deplab"
+)
+
+var _ = Describe("Providers", func() {
+	Describe("SelectProviders", func() {
+		It("returns all the available providers in the default order when none are selected", func() {
+			providers, err := SelectProviders(LabelProviders, nil, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ProviderNames(providers)).To(Equal(ProviderNames(LabelProviders)))
+		})
+
+		It("returns the selected providers in the given order", func() {
+			providers, err := SelectProviders(LabelProviders, []string{"os-release", "dpkg", "os-release"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ProviderNames(providers)).To(Equal([]string{"os-release", "dpkg"}))
+		})
+
+		It("leaves out the skipped providers", func() {
+			providers, err := SelectProviders(LabelProviders, nil, []string{"rpm", "os-release"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ProviderNames(providers)).ToNot(ContainElement("rpm"))
+			Expect(ProviderNames(providers)).ToNot(ContainElement("os-release"))
+			Expect(ProviderNames(providers)).To(ContainElement("dpkg"))
+		})
+
+		It("moves providers which must run last to the end", func() {
+			providers, err := SelectProviders(LabelProviders, []string{"provenance", "dpkg", "os-release"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ProviderNames(providers)).To(Equal([]string{"dpkg", "os-release", "provenance"}))
+		})
+
+		It("moves providers after the selected providers they read the metadata of", func() {
+			providers, err := SelectProviders(LabelProviders, []string{"kpack", "eol", "dpkg", "git", "os-release"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ProviderNames(providers)).To(Equal([]string{"dpkg", "git", "kpack", "os-release", "eol"}))
+		})
+
+		It("does not move providers after providers which are not selected", func() {
+			providers, err := SelectProviders(LabelProviders, []string{"eol", "kpack", "dpkg"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ProviderNames(providers)).To(Equal([]string{"eol", "kpack", "dpkg"}))
+		})
+
+		It("does not modify the selected providers", func() {
+			selected := make([]string, 1, 2)
+			selected[0] = "dpkg"
+
+			_, err := SelectProviders(LabelProviders, selected, []string{"rpm"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(selected[:2]).To(Equal([]string{"dpkg", ""}))
+		})
+
+		It("returns an error for unknown provider names", func() {
+			_, err := SelectProviders(LabelProviders, []string{"dpkg", "apk"}, []string{"npm"})
+
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring("unknown provider(s) apk, npm"),
+				ContainSubstring("dpkg, rpm"),
+			)))
+		})
+	})
+})
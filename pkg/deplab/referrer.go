@@ -0,0 +1,119 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/referrer"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/sbom"
+)
+
+// writeSBOMReferrer attaches the metadata to the input image as an artifact
+// referring to it, leaving the image itself untouched. It returns the
+// reference of the artifact, <target>@<digest>.
+func writeSBOMReferrer(dli image.RootFSImage, params common.RunParams, md metadata.Metadata) (string, error) {
+	target, err := referrer.ParseTarget(params.SBOMReferrer)
+	if err != nil {
+		return "", err
+	}
+
+	subject, err := dli.Descriptor()
+	if err != nil {
+		return "", fmt.Errorf("could not describe the input image: %w", err)
+	}
+
+	created := time.Now()
+	tool := sbom.Tool{Name: Provenance.Name, Version: Provenance.Version, URL: Provenance.URL}
+
+	content, mediaType, err := sbom.Generate(md, params.SBOMFormat, tool, created)
+	if err != nil {
+		return "", err
+	}
+
+	descriptor, err := target.Attach(content, mediaType, subject, created)
+	if err != nil {
+		return "", err
+	}
+
+	return target.String() + "@" + descriptor.Digest.String(), nil
+}
+
+// resolveLabelReference fetches the metadata stored at a reference written
+// by writeSBOMReferrer.
+func resolveLabelReference(reference string) ([]byte, error) {
+	i := strings.LastIndex(reference, "@")
+	if i < 0 {
+		return nil, fmt.Errorf("reference %s has no digest", reference)
+	}
+
+	target, err := referrer.ParseTarget(reference[:i])
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := v1.NewHash(reference[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("reference %s has an invalid digest: %w", reference, err)
+	}
+
+	return target.Fetch(digest)
+}
+
+// inspectSBOMReferrers prints the SBOMs found referring to the image.
+func inspectSBOMReferrers(dli image.RootFSImage, sbomReferrer string) error {
+	target, err := referrer.ParseTarget(sbomReferrer)
+	if err != nil {
+		return err
+	}
+
+	subject, err := dli.Digest()
+	if err != nil {
+		return fmt.Errorf("inspect cannot retrieve the digest of the provided image: %w", err)
+	}
+
+	referrers, err := target.Discover(subject, "")
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, r := range referrers {
+		if !isSBOMMediaType(r.Descriptor.ArtifactType) {
+			continue
+		}
+		found = true
+
+		stdOutBuffer := bytes.Buffer{}
+		err = json.Indent(&stdOutBuffer, r.Content, "", "  ")
+		if err != nil {
+			return fmt.Errorf("inspect cannot pretty print the sbom %s: %w", r.Descriptor.Digest, err)
+		}
+
+		fmt.Println(stdOutBuffer.String())
+	}
+
+	if !found {
+		return fmt.Errorf("inspect found no sbom referring to %s in %s", subject, target)
+	}
+
+	return nil
+}
+
+func isSBOMMediaType(mediaType string) bool {
+	switch mediaType {
+	case sbom.DeplabMediaType, sbom.SPDXMediaType, sbom.CycloneDXMediaType:
+		return true
+	}
+	return false
+}
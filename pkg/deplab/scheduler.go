@@ -0,0 +1,93 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// RunProviders runs the providers against the image and returns the
+// resulting metadata and the warnings of all the providers.
+//
+// Consecutive concurrent providers only read the image, so they run in
+// parallel, each one starting from empty metadata. Their results are merged
+// into md in the order of the providers, so the output does not depend on
+// which provider finishes first. Any other provider is a barrier: it runs on
+// its own with the metadata accumulated so far.
+func RunProviders(dli image.Image, params common.RunParams, md metadata.Metadata, providers []Provider) (metadata.Metadata, []common.Warning, error) {
+	var warnings []common.Warning
+
+	for len(providers) > 0 {
+		if !providers[0].Concurrent {
+			md2, providerWarnings, err := providers[0].Run(dli, params, md)
+			if err != nil {
+				return metadata.Metadata{}, nil, fmt.Errorf("provider %s failed: %w", providers[0].Name, err)
+			}
+			md = md2
+			warnings = append(warnings, providerWarnings...)
+			providers = providers[1:]
+			continue
+		}
+
+		batchSize := 1
+		for batchSize < len(providers) && providers[batchSize].Concurrent {
+			batchSize++
+		}
+
+		var (
+			batchWarnings []common.Warning
+			err           error
+		)
+		md, batchWarnings, err = runConcurrently(dli, params, md, providers[:batchSize])
+		if err != nil {
+			return metadata.Metadata{}, nil, err
+		}
+		warnings = append(warnings, batchWarnings...)
+		providers = providers[batchSize:]
+	}
+
+	return md, warnings, nil
+}
+
+func runConcurrently(dli image.Image, params common.RunParams, md metadata.Metadata, providers []Provider) (metadata.Metadata, []common.Warning, error) {
+	results := make([]metadata.Metadata, len(providers))
+	warnings := make([][]common.Warning, len(providers))
+	errs := make([]error, len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider Provider) {
+			defer wg.Done()
+			results[i], warnings[i], errs[i] = provider.Run(dli, params, metadata.Metadata{})
+		}(i, provider)
+	}
+	wg.Wait()
+
+	var allWarnings []common.Warning
+	for i, provider := range providers {
+		if errs[i] != nil {
+			return metadata.Metadata{}, nil, fmt.Errorf("provider %s failed: %w", provider.Name, errs[i])
+		}
+		md = mergeProviderResult(md, results[i])
+		allWarnings = append(allWarnings, warnings[i]...)
+	}
+
+	return md, allWarnings, nil
+}
+
+func mergeProviderResult(md, result metadata.Metadata) metadata.Metadata {
+	if len(result.Base) > 0 {
+		md.Base = metadata.MergeBase(md.Base, result.Base)
+	}
+	md.Provenance = append(md.Provenance, result.Provenance...)
+	md.Dependencies = append(md.Dependencies, result.Dependencies...)
+
+	return md
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/deplab"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/test/test_utils"
+)
+
+func dependencyProvider(name string, delay time.Duration) Provider {
+	return Provider{
+		Name: name,
+		Run: func(_ image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+			time.Sleep(delay)
+			md.Dependencies = append(md.Dependencies, metadata.Dependency{Type: name})
+			return md, []common.Warning{{Code: name}}, nil
+		},
+		Concurrent: true,
+	}
+}
+
+func dependencyTypes(md metadata.Metadata) []string {
+	var types []string
+	for _, dependency := range md.Dependencies {
+		types = append(types, dependency.Type)
+	}
+	return types
+}
+
+var _ = Describe("RunProviders", func() {
+	It("merges the results of concurrent providers in the order of the providers", func() {
+		md, warnings, err := RunProviders(test_utils.MockImage{}, common.RunParams{}, metadata.Metadata{}, []Provider{
+			dependencyProvider("slow", 50*time.Millisecond),
+			dependencyProvider("medium", 20*time.Millisecond),
+			dependencyProvider("fast", 0),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(dependencyTypes(md)).To(Equal([]string{"slow", "medium", "fast"}))
+		Expect(warnings).To(Equal([]common.Warning{{Code: "slow"}, {Code: "medium"}, {Code: "fast"}}))
+	})
+
+	It("runs concurrent providers in parallel", func() {
+		start := time.Now()
+		_, _, err := RunProviders(test_utils.MockImage{}, common.RunParams{}, metadata.Metadata{}, []Provider{
+			dependencyProvider("one", 100*time.Millisecond),
+			dependencyProvider("two", 100*time.Millisecond),
+			dependencyProvider("three", 100*time.Millisecond),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(time.Since(start)).To(BeNumerically("<", 300*time.Millisecond))
+	})
+
+	It("runs other providers with the metadata generated by the providers before them", func() {
+		var seen []string
+		barrier := Provider{
+			Name: "barrier",
+			Run: func(_ image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+				seen = dependencyTypes(md)
+				md.Provenance = append(md.Provenance, metadata.Provenance{Name: "barrier"})
+				return md, []common.Warning{{Code: "barrier"}}, nil
+			},
+		}
+
+		md, warnings, err := RunProviders(test_utils.MockImage{}, common.RunParams{}, metadata.Metadata{}, []Provider{
+			dependencyProvider("one", 10*time.Millisecond),
+			dependencyProvider("two", 0),
+			barrier,
+			dependencyProvider("three", 0),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(seen).To(Equal([]string{"one", "two"}))
+		Expect(dependencyTypes(md)).To(Equal([]string{"one", "two", "three"}))
+		Expect(md.Provenance).To(Equal([]metadata.Provenance{{Name: "barrier"}}))
+		Expect(warnings).To(Equal([]common.Warning{{Code: "one"}, {Code: "two"}, {Code: "barrier"}, {Code: "three"}}))
+	})
+
+	It("merges the base of concurrent providers", func() {
+		baseProvider := func(key, value string) Provider {
+			return Provider{
+				Name: key,
+				Run: func(_ image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+					md.Base = metadata.Base{key: value}
+					return md, nil, nil
+				},
+				Concurrent: true,
+			}
+		}
+
+		md, _, err := RunProviders(test_utils.MockImage{}, common.RunParams{}, metadata.Metadata{}, []Provider{
+			baseProvider("run_image", "cloudfoundry/run"),
+			baseProvider("name", "Ubuntu"),
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(md.Base).To(Equal(metadata.Base{"run_image": "cloudfoundry/run", "name": "Ubuntu"}))
+	})
+
+	It("returns the error of the first failing provider", func() {
+		failing := func(name string) Provider {
+			return Provider{
+				Name: name,
+				Run: func(image.Image, common.RunParams, metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+					return metadata.Metadata{}, nil, fmt.Errorf("%s is broken", name)
+				},
+				Concurrent: true,
+			}
+		}
+
+		_, _, err := RunProviders(test_utils.MockImage{}, common.RunParams{}, metadata.Metadata{}, []Provider{
+			dependencyProvider("one", 0),
+			failing("two"),
+			failing("three"),
+		})
+
+		Expect(err).To(MatchError("provider two failed: two is broken"))
+	})
+})
@@ -0,0 +1,211 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/referrer"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/signature"
+)
+
+// writeSignature signs the digest of the labeled image, or of its metadata
+// label, and stores the signature in a file and/or as a referrer of the
+// labeled image.
+func writeSignature(dli image.RootFSImage, params common.RunParams, md metadata.Metadata) error {
+	key, err := signature.LoadPrivateKey(params.SignKeyPath)
+	if err != nil {
+		return err
+	}
+
+	labeledImage, err := dli.DescriptorWithMetadata(md)
+	if err != nil {
+		return fmt.Errorf("could not describe the labeled image: %w", err)
+	}
+
+	target := params.SignTarget
+	if target == "" {
+		target = signature.ImageTarget
+	}
+
+	var digest string
+	switch target {
+	case signature.ImageTarget:
+		digest = labeledImage.Digest.String()
+	case signature.MetadataTarget:
+		label, err := json.Marshal(md)
+		if err != nil {
+			return fmt.Errorf("could not encode metadata: %w", err)
+		}
+		digest, err = signature.Digest(label)
+		if err != nil {
+			return fmt.Errorf("could not digest metadata: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported sign target %s", target)
+	}
+
+	created := time.Now()
+	payload := signature.NewPayload(target, params.Tag, digest, params.Signer, created)
+
+	envelope, err := signature.Sign(payload, key)
+	if err != nil {
+		return err
+	}
+
+	if params.SignatureFilePath != "" {
+		err = signature.WriteEnvelopeFile(envelope, params.SignatureFilePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if params.SignatureReferrer != "" {
+		referrerTarget, err := referrer.ParseTarget(params.SignatureReferrer)
+		if err != nil {
+			return err
+		}
+
+		content, err := json.Marshal(envelope)
+		if err != nil {
+			return fmt.Errorf("could not encode signature: %w", err)
+		}
+
+		_, err = referrerTarget.Attach(content, signature.MediaType, labeledImage, created)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunVerifySignature checks the signatures of a labeled image against a
+// public key and reports who signed which digest.
+func RunVerifySignature(inputImage, inputImageTar, publicKeyPath, signatureFilePath, signatureReferrer string) error {
+	publicKey, err := signature.LoadPublicKey(publicKeyPath)
+	if err != nil {
+		return err
+	}
+
+	dli, err := image.NewDeplabImage(inputImage, inputImageTar)
+	if err != nil {
+		return fmt.Errorf("verify-signature cannot open the provided image from '%s%s': %s", inputImage, inputImageTar, err)
+	}
+	defer dli.Cleanup()
+
+	envelopes, err := loadEnvelopes(dli, signatureFilePath, signatureReferrer)
+	if err != nil {
+		return err
+	}
+
+	if len(envelopes) == 0 {
+		return fmt.Errorf("no signature found for the image '%s%s'", inputImage, inputImageTar)
+	}
+
+	verified := 0
+	for _, envelope := range envelopes {
+		payload, err := signature.Verify(envelope, publicKey)
+		if err != nil {
+			fmt.Printf("signature by key %s could not be verified: %s\n", envelope.KeyID, err)
+			continue
+		}
+
+		digest, err := signedDigest(dli, payload.Subject.Target)
+		if err != nil {
+			return err
+		}
+
+		if payload.Subject.Digest != digest {
+			fmt.Printf("signature by key %s is for %s %s, not %s\n", envelope.KeyID, payload.Subject.Target, payload.Subject.Digest, digest)
+			continue
+		}
+
+		signer := payload.Signer
+		if signer == "" {
+			signer = "unknown signer"
+		}
+		fmt.Printf("verified signature by %s (key %s) of %s %s, signed on %s\n", signer, envelope.KeyID, payload.Subject.Target, payload.Subject.Digest, payload.Created)
+		verified++
+	}
+
+	if verified == 0 {
+		return fmt.Errorf("no valid signature found for the image '%s%s'", inputImage, inputImageTar)
+	}
+
+	return nil
+}
+
+func loadEnvelopes(dli image.RootFSImage, signatureFilePath, signatureReferrer string) ([]signature.Envelope, error) {
+	var envelopes []signature.Envelope
+
+	if signatureFilePath != "" {
+		envelope, err := signature.ReadEnvelopeFile(signatureFilePath)
+		if err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	if signatureReferrer != "" {
+		target, err := referrer.ParseTarget(signatureReferrer)
+		if err != nil {
+			return nil, err
+		}
+
+		digest, err := dli.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve image digest: %w", err)
+		}
+
+		referrers, err := target.Discover(digest, signature.MediaType)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range referrers {
+			var envelope signature.Envelope
+			err = json.Unmarshal(r.Content, &envelope)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse signature %s: %w", r.Descriptor.Digest, err)
+			}
+			envelopes = append(envelopes, envelope)
+		}
+	}
+
+	return envelopes, nil
+}
+
+// signedDigest returns the digest of the image, or of its metadata label,
+// to compare with a signed payload.
+func signedDigest(dli image.RootFSImage, target string) (string, error) {
+	switch target {
+	case signature.ImageTarget:
+		digest, err := dli.Digest()
+		if err != nil {
+			return "", fmt.Errorf("could not retrieve image digest: %w", err)
+		}
+		return digest.String(), nil
+	case signature.MetadataTarget:
+		cf, err := dli.GetConfig()
+		if err != nil {
+			return "", fmt.Errorf("cannot retrieve the Config file: %w", err)
+		}
+		label, ok, err := metadata.DecodeLabels(cf.Config.Labels, resolveLabelReference)
+		if err != nil {
+			return "", fmt.Errorf("cannot read the label %s: %w", metadata.Label, err)
+		}
+		if !ok {
+			return "", fmt.Errorf("the image has no %s label", metadata.Label)
+		}
+		return signature.Digest(label)
+	default:
+		return "", fmt.Errorf("unsupported signature target %s", target)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+)
+
+// FailOnAnyWarning can be given as a code to fail on every warning.
+const FailOnAnyWarning = "all"
+
+func PrintWarnings(w io.Writer, warnings []common.Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "deplab generated %d warning(s):\n", len(warnings))
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
+
+func WriteWarningsFile(warnings []common.Warning, warningsFilePath string) error {
+	warningsFile, err := os.Create(warningsFilePath)
+	if err != nil {
+		return fmt.Errorf("could not create file %s: %w", warningsFilePath, err)
+	}
+	defer warningsFile.Close()
+
+	if warnings == nil {
+		warnings = []common.Warning{}
+	}
+
+	encoder := json.NewEncoder(warningsFile)
+	err = encoder.Encode(warnings)
+	if err != nil {
+		return fmt.Errorf("could not write warnings file: %w", err)
+	}
+	return nil
+}
+
+// CheckFailOnEOL returns an error, when failOnEOL is set, if the base of the
+// image is past its end of life.
+func CheckFailOnEOL(warnings []common.Warning, failOnEOL bool) error {
+	if !failOnEOL {
+		return nil
+	}
+
+	for _, warning := range warnings {
+		if warning.Code == common.EndOfLifeBaseWarning {
+			return fmt.Errorf("failing on end of life base: %s", warning.Message)
+		}
+	}
+	return nil
+}
+
+// CheckFailOnWarning returns an error if any of the warnings has one of the
+// given codes.
+func CheckFailOnWarning(warnings []common.Warning, codes []string) error {
+	var failing []string
+	for _, warning := range warnings {
+		if contains(codes, FailOnAnyWarning) || contains(codes, warning.Code) {
+			failing = append(failing, warning.String())
+		}
+	}
+
+	if len(failing) != 0 {
+		return fmt.Errorf("failing on warning(s): %s", strings.Join(failing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package deplab_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/deplab"
+)
+
+var _ = Describe("Warnings", func() {
+	warnings := []common.Warning{
+		{Code: common.InvalidSourceURLWarning, Message: "failed to validate additional source url", DependencyType: "archive"},
+		{Code: common.MetadataMismatchWarning, Message: "difference identified", DependencyType: "base"},
+	}
+
+	Describe("PrintWarnings", func() {
+		It("prints a summary of the warnings", func() {
+			out := bytes.Buffer{}
+			PrintWarnings(&out, warnings)
+
+			Expect(out.String()).To(Equal(`deplab generated 2 warning(s):
+warning: [invalid-source-url] archive: failed to validate additional source url
+warning: [metadata-mismatch] base: difference identified
+`))
+		})
+
+		It("prints nothing when there are no warnings", func() {
+			out := bytes.Buffer{}
+			PrintWarnings(&out, nil)
+
+			Expect(out.String()).To(BeEmpty())
+		})
+	})
+
+	Describe("WriteWarningsFile", func() {
+		It("writes the warnings as json", func() {
+			f, err := ioutil.TempFile("", "deplab-warnings")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(f.Name())
+
+			Expect(WriteWarningsFile(warnings, f.Name())).To(Succeed())
+
+			var written []common.Warning
+			Expect(json.NewDecoder(f).Decode(&written)).To(Succeed())
+			Expect(written).To(Equal(warnings))
+		})
+	})
+
+	Describe("CheckFailOnWarning", func() {
+		It("returns an error if a warning has one of the codes", func() {
+			err := CheckFailOnWarning(warnings, []string{common.MetadataMismatchWarning})
+
+			Expect(err).To(MatchError(ContainSubstring("[metadata-mismatch] base: difference identified")))
+			Expect(err).ToNot(MatchError(ContainSubstring("invalid-source-url")))
+		})
+
+		It("returns an error for any warning with all", func() {
+			Expect(CheckFailOnWarning(warnings, []string{FailOnAnyWarning})).To(HaveOccurred())
+		})
+
+		It("does not return an error if no warning has one of the codes", func() {
+			Expect(CheckFailOnWarning(warnings, []string{"some-other-code"})).To(Succeed())
+			Expect(CheckFailOnWarning(warnings, nil)).To(Succeed())
+		})
+	})
+
+	Describe("CheckFailOnEOL", func() {
+		eolWarnings := append(warnings, common.Warning{
+			Code:    common.EndOfLifeBaseWarning,
+			Message: "base debian 9 reached its end of life on 2020-07-06",
+		})
+
+		It("returns an error if the base is past its end of life", func() {
+			Expect(CheckFailOnEOL(eolWarnings, true)).To(MatchError("failing on end of life base: base debian 9 reached its end of life on 2020-07-06"))
+		})
+
+		It("does not return an error unless asked to", func() {
+			Expect(CheckFailOnEOL(eolWarnings, false)).To(Succeed())
+		})
+
+		It("does not return an error if the base is supported", func() {
+			Expect(CheckFailOnEOL(warnings, true)).To(Succeed())
+		})
+	})
+})
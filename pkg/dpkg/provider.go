@@ -18,6 +18,8 @@ import (
 	"golang.org/x/text/language"
 )
 
+const DpkgStatusPath = "/var/lib/dpkg/status"
+
 //func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
 //	dependency, err := BuildDependencyMetadata(dli)
 //	if err != nil {
@@ -27,13 +29,13 @@ import (
 //	return md, nil
 //}
 
-func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
-	packages := getDebianPackages(dli)
+func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	packages := getDebianPackages(dli, params.ProviderOptions.Dpkg.StatusPaths)
 
 	if len(packages) != 0 {
 		sources, err := getAptSources(dli)
 		if err != nil {
-			return metadata.Metadata{}, fmt.Errorf("could not get apt sources: %w", err)
+			return metadata.Metadata{}, nil, fmt.Errorf("could not get apt sources: %w", err)
 		}
 
 		sourceMetadata := metadata.DebianPackageListSourceMetadata{
@@ -43,7 +45,7 @@ func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (m
 
 		version, err := common.Digest(sourceMetadata)
 		if err != nil {
-			return metadata.Metadata{}, fmt.Errorf("could not get digest for source metadata: %w", err)
+			return metadata.Metadata{}, nil, fmt.Errorf("could not get digest for source metadata: %w", err)
 		}
 
 		md.Dependencies = append(md.Dependencies, metadata.Dependency{
@@ -58,7 +60,7 @@ func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (m
 		})
 
 	}
-	return md, nil
+	return md, nil, nil
 }
 
 func getAptSources(dli image.Image) ([]string, error) {
@@ -92,11 +94,14 @@ func getAptSources(dli image.Image) ([]string, error) {
 	return sources, nil
 }
 
-func getDebianPackages(dli image.Image) ([]metadata.DpkgPackage) {
+func getDebianPackages(dli image.Image, extraStatusPaths []string) []metadata.DpkgPackage {
 	var packages []metadata.DpkgPackage
 
-	packages = append(packages, listPackagesFromStatus(dli)...)
+	packages = append(packages, listPackagesFromStatus(dli, DpkgStatusPath)...)
 	packages = append(packages, listPackagesFromStatusD(dli)...)
+	for _, statusPath := range extraStatusPaths {
+		packages = append(packages, listPackagesFromStatus(dli, statusPath)...)
+	}
 
 	collator := collate.New(language.BritishEnglish)
 	sort.Slice(packages, func(i, j int) bool {
@@ -174,8 +179,8 @@ func listPackagesFromStatusD(dli image.Image) (packages []metadata.DpkgPackage)
 	return packages
 }
 
-func listPackagesFromStatus(dli image.Image) (packages []metadata.DpkgPackage) {
-	statDBString, err := dli.GetFileContent("/var/lib/dpkg/status")
+func listPackagesFromStatus(dli image.Image, statusPath string) (packages []metadata.DpkgPackage) {
+	statDBString, err := dli.GetFileContent(statusPath)
 	if err != nil {
 		// in this case an empty or non-existent file is not an error
 		statDBString = ""
@@ -54,7 +54,7 @@ Original-Maintainer: Debian GCC Maintainers <debian-gcc@lists.debian.org>`)).To(
 	Describe("Provider", func() {
 		Context("when the image has no database packages", func() {
 			It("does not modify the metadata content", func() {
-				md, err := Provider(test_utils.MockImage{}, common.RunParams{}, metadata.Metadata{})
+				md, _, err := Provider(test_utils.MockImage{}, common.RunParams{}, metadata.Metadata{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(md).To(Equal(metadata.Metadata{}))
@@ -0,0 +1,137 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package eol
+
+import (
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DateLayout is the layout of the dates of the lifecycle data.
+const DateLayout = "2006-01-02"
+
+//go:embed eol.yml
+var bundledData []byte
+
+// Data is the lifecycle of the releases of each distribution.
+type Data struct {
+	Distributions []Distribution `yaml:"distributions"`
+}
+
+type Distribution struct {
+	ID       string    `yaml:"id"`
+	Releases []Release `yaml:"releases"`
+}
+
+// Release is the lifecycle of a release of a distribution. End of life is the
+// end of its standard support.
+type Release struct {
+	VersionID            string `yaml:"version_id"`
+	ReleaseDate          string `yaml:"release_date"`
+	EndOfStandardSupport string `yaml:"end_of_standard_support"`
+	EndOfExtendedSupport string `yaml:"end_of_extended_support"`
+}
+
+// LoadData returns the bundled lifecycle data, with the releases of the data
+// file at dataFilePath, if any, taking precedence.
+func LoadData(dataFilePath string) (Data, error) {
+	data, err := parseData(bundledData)
+	if err != nil {
+		return Data{}, fmt.Errorf("could not parse bundled eol data: %w", err)
+	}
+
+	if dataFilePath == "" {
+		return data, nil
+	}
+
+	content, err := ioutil.ReadFile(dataFilePath)
+	if err != nil {
+		return Data{}, fmt.Errorf("could not read eol data file %s: %w", dataFilePath, err)
+	}
+
+	fileData, err := parseData(content)
+	if err != nil {
+		return Data{}, fmt.Errorf("could not parse eol data file %s: %w", dataFilePath, err)
+	}
+
+	// looked up first, the releases of the file hide the bundled ones
+	return Data{Distributions: append(fileData.Distributions, data.Distributions...)}, nil
+}
+
+func parseData(content []byte) (Data, error) {
+	var data Data
+	err := yaml.UnmarshalStrict(content, &data)
+	if err != nil {
+		return Data{}, err
+	}
+
+	for _, distribution := range data.Distributions {
+		if distribution.ID == "" {
+			return Data{}, fmt.Errorf("a distribution requires an id")
+		}
+		for _, release := range distribution.Releases {
+			if release.VersionID == "" {
+				return Data{}, fmt.Errorf("a release of %s requires a version_id", distribution.ID)
+			}
+			if release.EndOfStandardSupport == "" {
+				return Data{}, fmt.Errorf("release %s %s requires an end_of_standard_support", distribution.ID, release.VersionID)
+			}
+			for _, date := range []string{release.ReleaseDate, release.EndOfStandardSupport, release.EndOfExtendedSupport} {
+				if _, err := parseDate(date); err != nil {
+					return Data{}, fmt.Errorf("release %s %s has an invalid date: %w", distribution.ID, release.VersionID, err)
+				}
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// Lookup returns the release of the distribution with the version id. A
+// version id with more components than known releases matches the release of
+// its leading components, e.g. alpine 3.15.0 matches 3.15.
+func (d Data) Lookup(id, versionID string) (Release, bool) {
+	for versionID != "" {
+		for _, distribution := range d.Distributions {
+			if distribution.ID != id {
+				continue
+			}
+			for _, release := range distribution.Releases {
+				if release.VersionID == versionID {
+					return release, true
+				}
+			}
+		}
+
+		i := strings.LastIndex(versionID, ".")
+		if i < 0 {
+			break
+		}
+		versionID = versionID[:i]
+	}
+
+	return Release{}, false
+}
+
+// IsEndOfLife reports whether the standard support of the release ended
+// before the given time. The release is supported on its end of support date.
+func (r Release) IsEndOfLife(now time.Time) bool {
+	end, err := parseDate(r.EndOfStandardSupport)
+	if err != nil || end.IsZero() {
+		return false
+	}
+	return !now.Before(end.AddDate(0, 0, 1))
+}
+
+func parseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(DateLayout, date)
+}
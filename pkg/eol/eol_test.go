@@ -0,0 +1,155 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package eol_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/eol"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/test/test_utils"
+)
+
+var _ = Describe("EOL", func() {
+	var dataFilePath string
+
+	writeDataFile := func(content string) string {
+		f, err := ioutil.TempFile("", "deplab-eol")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.WriteString(content)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+		dataFilePath = f.Name()
+		return dataFilePath
+	}
+
+	AfterEach(func() {
+		if dataFilePath != "" {
+			os.Remove(dataFilePath)
+		}
+	})
+
+	Describe("LoadData", func() {
+		It("loads the bundled data", func() {
+			data, err := LoadData("")
+			Expect(err).ToNot(HaveOccurred())
+
+			release, ok := data.Lookup("ubuntu", "18.04")
+			Expect(ok).To(BeTrue())
+			Expect(release).To(Equal(Release{
+				VersionID:            "18.04",
+				ReleaseDate:          "2018-04-26",
+				EndOfStandardSupport: "2023-05-31",
+				EndOfExtendedSupport: "2028-04-30",
+			}))
+		})
+
+		It("prefers the releases of the data file to the bundled ones", func() {
+			data, err := LoadData(writeDataFile(`distributions:
+- id: ubuntu
+  releases:
+  - version_id: "18.04"
+    end_of_standard_support: "2099-01-01"
+`))
+			Expect(err).ToNot(HaveOccurred())
+
+			release, ok := data.Lookup("ubuntu", "18.04")
+			Expect(ok).To(BeTrue())
+			Expect(release.EndOfStandardSupport).To(Equal("2099-01-01"))
+
+			_, ok = data.Lookup("ubuntu", "20.04")
+			Expect(ok).To(BeTrue())
+		})
+
+		It("returns an error for an invalid date", func() {
+			_, err := LoadData(writeDataFile(`distributions:
+- id: example
+  releases:
+  - version_id: "1"
+    end_of_standard_support: "31/12/2020"
+`))
+			Expect(err).To(MatchError(ContainSubstring("release example 1 has an invalid date")))
+		})
+
+		It("returns an error for a release without end of support", func() {
+			_, err := LoadData(writeDataFile(`distributions:
+- id: example
+  releases:
+  - version_id: "1"
+`))
+			Expect(err).To(MatchError(ContainSubstring("release example 1 requires an end_of_standard_support")))
+		})
+	})
+
+	Describe("Lookup", func() {
+		data := Data{Distributions: []Distribution{{
+			ID:       "alpine",
+			Releases: []Release{{VersionID: "3.15", EndOfStandardSupport: "2023-11-01"}},
+		}}}
+
+		It("matches a version id by its leading components", func() {
+			release, ok := data.Lookup("alpine", "3.15.0")
+			Expect(ok).To(BeTrue())
+			Expect(release.VersionID).To(Equal("3.15"))
+		})
+
+		It("does not match other versions", func() {
+			_, ok := data.Lookup("alpine", "3.1")
+			Expect(ok).To(BeFalse())
+			_, ok = data.Lookup("debian", "3.15")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("IsEndOfLife", func() {
+		release := Release{EndOfStandardSupport: "2023-11-01"}
+
+		It("is supported until the end of its end of support date", func() {
+			Expect(release.IsEndOfLife(time.Date(2023, 11, 1, 23, 0, 0, 0, time.UTC))).To(BeFalse())
+			Expect(release.IsEndOfLife(time.Date(2023, 11, 2, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+		})
+	})
+
+	Describe("Provider", func() {
+		It("adds the lifecycle of the release to the base", func() {
+			md, warnings, err := Provider(test_utils.NewMockImageWithEmptyConfig(), common.RunParams{}, metadata.Metadata{
+				Base: metadata.Base{"id": "alpine", "version_id": "3.15.0"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(md.Base).To(Equal(metadata.Base{
+				"id":                      "alpine",
+				"version_id":              "3.15.0",
+				"release_date":            "2021-11-24",
+				"end_of_standard_support": "2023-11-01",
+			}))
+			Expect(warnings).To(ConsistOf(common.Warning{
+				Code:    common.EndOfLifeBaseWarning,
+				Message: "base alpine 3.15.0 reached its end of life on 2023-11-01",
+			}))
+		})
+
+		It("does not warn for a supported release", func() {
+			_, warnings, err := Provider(test_utils.NewMockImageWithEmptyConfig(), common.RunParams{EOLDataPath: writeDataFile(`distributions:
+- id: example
+  releases:
+  - version_id: "1"
+    end_of_standard_support: "2999-01-01"
+`)}, metadata.Metadata{Base: metadata.Base{"id": "example", "version_id": "1"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("does not modify the base of an unknown release", func() {
+			md := metadata.Metadata{Base: metadata.Base{"name": "scratch", "version_id": "unknown"}}
+
+			Expect(Provider(test_utils.NewMockImageWithEmptyConfig(), common.RunParams{}, md)).To(Equal(md))
+		})
+	})
+})
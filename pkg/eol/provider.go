@@ -0,0 +1,50 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package eol
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// Provider adds the lifecycle of the release of the base, identified by the
+// id and version_id already in the base, to the base. A base past its end of
+// life is reported as a warning.
+func Provider(_ image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	data, err := LoadData(params.EOLDataPath)
+	if err != nil {
+		return metadata.Metadata{}, nil, err
+	}
+
+	id, versionID := md.Base["id"], md.Base["version_id"]
+	release, ok := data.Lookup(id, versionID)
+	if !ok {
+		return md, nil, nil
+	}
+
+	lifecycle := metadata.Base{
+		metadata.BaseReleaseDateKey:          release.ReleaseDate,
+		metadata.BaseEndOfStandardSupportKey: release.EndOfStandardSupport,
+		metadata.BaseEndOfExtendedSupportKey: release.EndOfExtendedSupport,
+	}
+	for k, v := range lifecycle {
+		if v == "" {
+			delete(lifecycle, k)
+		}
+	}
+	md.Base = metadata.MergeBase(md.Base, lifecycle)
+
+	if release.IsEndOfLife(time.Now()) {
+		return md, []common.Warning{{
+			Code:    common.EndOfLifeBaseWarning,
+			Message: fmt.Sprintf("base %s %s reached its end of life on %s", id, versionID, release.EndOfStandardSupport),
+		}}, nil
+	}
+
+	return md, nil, nil
+}
@@ -5,6 +5,9 @@ package git
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
 
@@ -14,24 +17,62 @@ import (
 
 	"gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
-func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
+// DetachedBranch is recorded as the branch when HEAD is not on a branch.
+const DetachedBranch = "detached"
+
+// Policies for git repositories with uncommitted changes.
+const (
+	DirtyAllow = "allow"
+	DirtyWarn  = "warn"
+	DirtyFail  = "fail"
+)
+
+var DirtyPolicies = []string{DirtyAllow, DirtyWarn, DirtyFail}
+
+func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	var warnings []common.Warning
 	for _, path := range params.GitPaths {
-		dependency, err := BuildDependencyMetadata(path)
+		dependency, err := BuildDependencyMetadata(path, params.GitRemote)
 		if err != nil {
-			return metadata.Metadata{}, err
+			return metadata.Metadata{}, nil, err
+		}
+
+		if dirtyFiles := dependency.Source.Metadata.(metadata.GitSourceMetadata).DirtyFiles; dirtyFiles != nil {
+			message := fmt.Sprintf("git repository %s has %d uncommitted change(s)", path, dirtyFiles.Count)
+			switch params.GitDirty {
+			case DirtyFail:
+				return metadata.Metadata{}, nil, fmt.Errorf("%s", message)
+			case DirtyWarn:
+				warnings = append(warnings, common.Warning{
+					Code:           common.DirtyGitTreeWarning,
+					Message:        message,
+					DependencyType: metadata.GitSourceType,
+				})
+			}
 		}
+
 		md.Dependencies = append(md.Dependencies, dependency)
+
+		submoduleDependencies, err := BuildSubmoduleDependencies(path, params.GitRemote)
+		if err != nil {
+			return metadata.Metadata{}, nil, err
+		}
+		md.Dependencies = append(md.Dependencies, submoduleDependencies...)
 	}
 
-	return md, nil
+	return md, warnings, nil
 }
 
-func BuildDependencyMetadata(pathToGit string) (metadata.Dependency, error) {
-	repo, err := git.PlainOpen(pathToGit)
+// BuildDependencyMetadata describes the HEAD of the git repository enclosing
+// the path, recorded with the url of the named remote. When no remote is
+// named, origin is preferred, otherwise the first remote by name is used.
+func BuildDependencyMetadata(pathToGit, remoteName string) (metadata.Dependency, error) {
+	repo, subdirectory, err := openRepository(pathToGit)
 	if err != nil {
-		return metadata.Dependency{}, fmt.Errorf("cannot open git repository \"%s\": %s\n", pathToGit, err)
+		return metadata.Dependency{}, err
 	}
 
 	ref, err := repo.Head()
@@ -39,24 +80,37 @@ func BuildDependencyMetadata(pathToGit string) (metadata.Dependency, error) {
 		return metadata.Dependency{}, fmt.Errorf("cannot find head of git repository: %s\n", err)
 	}
 
-	remotes, err := repo.Remotes()
+	remoteURL, err := remoteURL(repo, pathToGit, remoteName)
 	if err != nil {
-		return metadata.Dependency{}, fmt.Errorf("cannot find remotes for repository: %s\n", err)
+		return metadata.Dependency{}, err
 	}
 
-	var refs []string
-	tags, err := repo.Tags()
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return metadata.Dependency{}, fmt.Errorf("cannot find commit of head of git repository: %s\n", err)
+	}
+
+	tagsByCommit, err := tagsByCommit(repo)
 	if err != nil {
 		return metadata.Dependency{}, fmt.Errorf("error finding tags: %s\n", err)
 	}
 
-	tags.ForEach(func(tagRef *plumbing.Reference) error {
-		if tagRef.Type() == plumbing.HashReference && tagRef.Hash() == ref.Hash() {
-			refs = append(refs, tagRef.Name().Short())
-		}
+	describe, err := describe(repo, commit, tagsByCommit)
+	if err != nil {
+		return metadata.Dependency{}, fmt.Errorf("cannot describe head of git repository: %s\n", err)
+	}
 
-		return nil
-	})
+	refs := tagsByCommit[ref.Hash()]
+
+	dirtyFiles, err := dirtyFiles(repo)
+	if err != nil {
+		return metadata.Dependency{}, fmt.Errorf("cannot find status of git repository: %s\n", err)
+	}
+
+	branch := DetachedBranch
+	if ref.Name().IsBranch() {
+		branch = ref.Name().Short()
+	}
 
 	return metadata.Dependency{
 		Type: "package",
@@ -66,9 +120,190 @@ func BuildDependencyMetadata(pathToGit string) (metadata.Dependency, error) {
 				"commit": ref.Hash().String(),
 			},
 			Metadata: metadata.GitSourceMetadata{
-				URL:  remotes[0].Config().URLs[0],
-				Refs: refs,
+				URL:             NormalizeRemoteURL(remoteURL),
+				Refs:            refs,
+				Subdirectory:    subdirectory,
+				Branch:          branch,
+				Describe:        describe,
+				CommitTimestamp: commit.Committer.When.UTC().Format(time.RFC3339),
+				Author:          fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email),
+				Dirty:           dirtyFiles != nil,
+				DirtyFiles:      dirtyFiles,
 			},
 		},
 	}, nil
 }
+
+// remoteURL returns the url of the named remote, or of the remote selected
+// when none is named, as configured.
+func remoteURL(repo *git.Repository, pathToGit, remoteName string) (string, error) {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return "", fmt.Errorf("cannot find remotes for repository: %s\n", err)
+	}
+
+	var names []string
+	remotesByName := map[string]*git.Remote{}
+	for _, remote := range remotes {
+		if len(remote.Config().URLs) == 0 {
+			continue
+		}
+		names = append(names, remote.Config().Name)
+		remotesByName[remote.Config().Name] = remote
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("git repository \"%s\" has no remotes\n", pathToGit)
+	}
+
+	if remoteName == "" {
+		remoteName = names[0]
+		if _, ok := remotesByName[git.DefaultRemoteName]; ok {
+			remoteName = git.DefaultRemoteName
+		}
+	}
+
+	remote, ok := remotesByName[remoteName]
+	if !ok {
+		return "", fmt.Errorf("cannot find remote \"%s\" in git repository \"%s\", available remotes: %s\n", remoteName, pathToGit, strings.Join(names, ", "))
+	}
+
+	return remote.Config().URLs[0], nil
+}
+
+// dirtyFiles returns the modified and untracked files of the working tree,
+// or nil if it is clean or the repository is bare.
+func dirtyFiles(repo *git.Repository) (*metadata.GitDirtyFiles, error) {
+	worktree, err := repo.Worktree()
+	if err == git.ErrIsBareRepository {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	if status.IsClean() {
+		return nil, nil
+	}
+
+	dirtyFiles := metadata.GitDirtyFiles{Modified: []string{}, Untracked: []string{}}
+	for path, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked {
+			dirtyFiles.Untracked = append(dirtyFiles.Untracked, path)
+		} else if fileStatus.Staging != git.Unmodified || fileStatus.Worktree != git.Unmodified {
+			dirtyFiles.Modified = append(dirtyFiles.Modified, path)
+		}
+	}
+	sort.Strings(dirtyFiles.Modified)
+	sort.Strings(dirtyFiles.Untracked)
+	dirtyFiles.Count = len(dirtyFiles.Modified) + len(dirtyFiles.Untracked)
+
+	return &dirtyFiles, nil
+}
+
+// tagsByCommit returns the names of the lightweight and annotated tags of
+// the repository, by the commit they point to.
+func tagsByCommit(repo *git.Repository) (map[plumbing.Hash][]string, error) {
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	tagsByCommit := map[plumbing.Hash][]string{}
+	err = tags.ForEach(func(tagRef *plumbing.Reference) error {
+		if tagRef.Type() != plumbing.HashReference {
+			return nil
+		}
+
+		target, err := peel(repo, tagRef.Hash())
+		if err != nil {
+			return fmt.Errorf("cannot peel tag %s: %w", tagRef.Name().Short(), err)
+		}
+
+		tagsByCommit[target] = append(tagsByCommit[target], tagRef.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, names := range tagsByCommit {
+		sort.Strings(names)
+	}
+
+	return tagsByCommit, nil
+}
+
+// peel follows annotated tag objects to the object they finally point to.
+func peel(repo *git.Repository, hash plumbing.Hash) (plumbing.Hash, error) {
+	for {
+		tag, err := repo.TagObject(hash)
+		if err == plumbing.ErrObjectNotFound {
+			return hash, nil
+		}
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		hash = tag.Target
+	}
+}
+
+// describe finds the tag nearest to the commit by walking its history
+// breadth first, or nil if no tag is reachable. The walk stops at the edge of
+// a shallow clone.
+func describe(repo *git.Repository, head *object.Commit, tagsByCommit map[plumbing.Hash][]string) (*metadata.GitDescribe, error) {
+	if len(tagsByCommit) == 0 {
+		return nil, nil
+	}
+
+	visited := map[plumbing.Hash]bool{head.Hash: true}
+	generation := []*object.Commit{head}
+
+	for distance := 0; len(generation) > 0; distance++ {
+		var tagged []string
+		for _, commit := range generation {
+			tagged = append(tagged, tagsByCommit[commit.Hash]...)
+		}
+
+		if len(tagged) > 0 {
+			sort.Strings(tagged)
+			description := tagged[0]
+			if distance > 0 {
+				description = fmt.Sprintf("%s-%d-g%s", tagged[0], distance, head.Hash.String()[:7])
+			}
+			return &metadata.GitDescribe{
+				Tag:         tagged[0],
+				Distance:    distance,
+				Description: description,
+			}, nil
+		}
+
+		var next []*object.Commit
+		for _, commit := range generation {
+			for _, parentHash := range commit.ParentHashes {
+				if visited[parentHash] {
+					continue
+				}
+				visited[parentHash] = true
+
+				parent, err := repo.CommitObject(parentHash)
+				if err == plumbing.ErrObjectNotFound {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, parent)
+			}
+		}
+		generation = next
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+)
+
+// openRepository opens the git repository enclosing the path, and returns
+// the path relative to the root of the repository, empty for the root itself.
+func openRepository(pathToGit string) (*git.Repository, string, error) {
+	repo, err := git.PlainOpenWithOptions(pathToGit, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot open git repository \"%s\": %s\n", pathToGit, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err == git.ErrIsBareRepository {
+		return repo, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot open git repository \"%s\": %s\n", pathToGit, err)
+	}
+
+	absolutePath, err := filepath.Abs(pathToGit)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot open git repository \"%s\": %s\n", pathToGit, err)
+	}
+
+	subdirectory, err := filepath.Rel(worktree.Filesystem.Root(), absolutePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot open git repository \"%s\": %s\n", pathToGit, err)
+	}
+
+	if subdirectory == "." {
+		return repo, "", nil
+	}
+	return repo, filepath.ToSlash(subdirectory), nil
+}
+
+// BuildSubmoduleDependencies describes the initialized submodules of the git
+// repository enclosing the path, and of their own submodules, at the commits
+// pinned by their parent repository. When the path is a subdirectory of the
+// repository, only the submodules within it are described. Relative submodule
+// urls are resolved against the url of the remote of the repository, selected
+// as by BuildDependencyMetadata.
+func BuildSubmoduleDependencies(pathToGit, remoteName string) ([]metadata.Dependency, error) {
+	repo, subdirectory, err := openRepository(pathToGit)
+	if err != nil {
+		return nil, err
+	}
+
+	parentURL, err := remoteURL(repo, pathToGit, remoteName)
+	if err != nil {
+		return nil, err
+	}
+
+	return submoduleDependencies(repo, parentURL, "", subdirectory)
+}
+
+func submoduleDependencies(repo *git.Repository, parentURL, parentPath, subdirectory string) ([]metadata.Dependency, error) {
+	worktree, err := repo.Worktree()
+	if err == git.ErrIsBareRepository {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find submodules of git repository: %s\n", err)
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read index of git repository: %s\n", err)
+	}
+
+	var dependencies []metadata.Dependency
+	for _, submodule := range submodules {
+		submoduleConfig := submodule.Config()
+
+		if subdirectory != "" && submoduleConfig.Path != subdirectory && !strings.HasPrefix(submoduleConfig.Path, subdirectory+"/") {
+			continue
+		}
+
+		// a submodule which has not been cloned has no .git in its directory
+		submoduleRoot := filepath.Join(worktree.Filesystem.Root(), filepath.FromSlash(submoduleConfig.Path))
+		if _, err := os.Stat(filepath.Join(submoduleRoot, git.GitDirName)); err != nil {
+			continue
+		}
+
+		entry, err := idx.Entry(submoduleConfig.Path)
+		if err == index.ErrEntryNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot find pinned commit of submodule \"%s\": %s\n", submoduleConfig.Path, err)
+		}
+
+		submoduleRepo, err := git.PlainOpen(submoduleRoot)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open submodule \"%s\": %s\n", submoduleConfig.Path, err)
+		}
+
+		tagsByCommit, err := tagsByCommit(submoduleRepo)
+		if err != nil {
+			return nil, fmt.Errorf("error finding tags of submodule \"%s\": %s\n", submoduleConfig.Path, err)
+		}
+
+		submodulePath := path.Join(parentPath, submoduleConfig.Path)
+		submoduleURL := resolveSubmoduleURL(parentURL, submoduleConfig.URL)
+		dependencies = append(dependencies, metadata.Dependency{
+			Type: "package",
+			Source: metadata.Source{
+				Type: metadata.GitSourceType,
+				Version: map[string]interface{}{
+					"commit": entry.Hash.String(),
+				},
+				Metadata: metadata.GitSourceMetadata{
+					URL:           NormalizeRemoteURL(submoduleURL),
+					Refs:          tagsByCommit[entry.Hash],
+					SubmodulePath: submodulePath,
+				},
+			},
+		})
+
+		nestedDependencies, err := submoduleDependencies(submoduleRepo, submoduleURL, submodulePath, "")
+		if err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, nestedDependencies...)
+	}
+
+	return dependencies, nil
+}
+
+// resolveSubmoduleURL resolves a submodule url relative to the url of its
+// parent repository, e.g. ../lib.git, as git does. Other urls are returned as
+// they are.
+func resolveSubmoduleURL(parentURL, submoduleURL string) string {
+	if !strings.HasPrefix(submoduleURL, "./") && !strings.HasPrefix(submoduleURL, "../") {
+		return submoduleURL
+	}
+
+	base := strings.TrimSuffix(parentURL, "/")
+	for {
+		if strings.HasPrefix(submoduleURL, "./") {
+			submoduleURL = strings.TrimPrefix(submoduleURL, "./")
+		} else if strings.HasPrefix(submoduleURL, "../") {
+			submoduleURL = strings.TrimPrefix(submoduleURL, "../")
+			// the path of scp-style urls starts after the colon
+			i := strings.LastIndexAny(base, "/:")
+			if i >= 0 && base[i] == ':' {
+				base = base[:i+1]
+			} else if i >= 0 && !strings.HasSuffix(base[:i+1], "://") {
+				base = base[:i]
+			}
+		} else {
+			break
+		}
+	}
+
+	if strings.HasSuffix(base, ":") {
+		return base + submoduleURL
+	}
+	return base + "/" + submoduleURL
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package git
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// scpLikeURLPattern matches scp-style remotes such as git@github.com:org/repo.git
+var scpLikeURLPattern = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// NormalizeRemoteURL strips any userinfo, e.g. credentials embedded in a CI
+// clone url, from a remote url, and records ssh and scp-style remotes in
+// their https form so that the same repository is always recorded with the
+// same url. Remotes which are not urls, e.g. local paths, are returned as
+// they are.
+func NormalizeRemoteURL(remoteURL string) string {
+	if !strings.Contains(remoteURL, "://") {
+		matches := scpLikeURLPattern.FindStringSubmatch(remoteURL)
+		if matches == nil {
+			return remoteURL
+		}
+		return (&url.URL{Scheme: "https", Host: matches[1], Path: "/" + strings.TrimPrefix(matches[2], "/")}).String()
+	}
+
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return remoteURL
+	}
+
+	u.User = nil
+	switch u.Scheme {
+	case "ssh", "git+ssh", "ssh+git":
+		u.Scheme = "https"
+		u.Host = u.Hostname()
+	}
+
+	return u.String()
+}
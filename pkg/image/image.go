@@ -4,7 +4,6 @@
 package image
 
 import (
-	"encoding/json"
 	"fmt"
 	"path"
 	"path/filepath"
@@ -33,8 +32,9 @@ type ExportableImage interface {
 }
 
 type RootFSImage struct {
-	rootFS RootFS
-	image  v1.Image
+	rootFS       RootFS
+	image        v1.Image
+	labelOptions metadata.LabelOptions
 }
 
 func (dli RootFSImage) GetConfig() (*v1.ConfigFile, error) {
@@ -74,6 +74,11 @@ func (dli *RootFSImage) Cleanup() {
 	dli.rootFS.Cleanup()
 }
 
+// SetLabelOptions sets how the metadata is stored in the labels of the image.
+func (dli *RootFSImage) SetLabelOptions(options metadata.LabelOptions) {
+	dli.labelOptions = options
+}
+
 func (dli RootFSImage) ExportWithMetadata(metadata metadata.Metadata, path string, tag string) error {
 	err := dli.setMetadata(metadata)
 	if err != nil {
@@ -87,6 +92,56 @@ func (dli RootFSImage) ExportWithMetadata(metadata metadata.Metadata, path strin
 	return nil
 }
 
+func (dli RootFSImage) Digest() (v1.Hash, error) {
+	return dli.image.Digest()
+}
+
+// Descriptor describes the input image, untouched by deplab.
+func (dli RootFSImage) Descriptor() (v1.Descriptor, error) {
+	digest, err := dli.image.Digest()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+
+	size, err := dli.image.Size()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+
+	mediaType, err := dli.image.MediaType()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+
+	return v1.Descriptor{MediaType: mediaType, Size: size, Digest: digest}, nil
+}
+
+// DescriptorWithMetadata describes the image once labeled with the metadata.
+func (dli RootFSImage) DescriptorWithMetadata(metadata metadata.Metadata) (v1.Descriptor, error) {
+	err := dli.setMetadata(metadata)
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("error setting metadata: %w", err)
+	}
+
+	return dli.Descriptor()
+}
+
+// DigestWithMetadata returns the digest the image will have once labeled with
+// the metadata.
+func (dli RootFSImage) DigestWithMetadata(metadata metadata.Metadata) (v1.Hash, error) {
+	err := dli.setMetadata(metadata)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("error setting metadata: %w", err)
+	}
+
+	return dli.image.Digest()
+}
+
+// Manifest is the manifest of the input image, untouched by deplab.
+func (dli RootFSImage) Manifest() (*v1.Manifest, error) {
+	return dli.image.Manifest()
+}
+
 func (dli RootFSImage) GetFileContent(s string) (string, error) {
 	return dli.rootFS.GetFileContent(s)
 }
@@ -99,20 +154,59 @@ func (dli RootFSImage) GetDirFileNames(s string, i bool) ([]string, error) {
 	return dli.rootFS.GetDirFileNames(s, i)
 }
 
-func (dli *RootFSImage) setMetadata(metadata metadata.Metadata) error {
+func (dli RootFSImage) LinkTarget(s string) (string, bool, error) {
+	return dli.rootFS.LinkTarget(s)
+}
+
+// ListDir returns the names of the files and of the directories in a
+// directory of the image.
+func ListDir(dli Image, dir string) ([]string, []string, error) {
+	files, err := dli.GetDirFileNames(dir, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := dli.GetDirFileNames(dir, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isFile := map[string]bool{}
+	for _, name := range files {
+		isFile[name] = true
+	}
+
+	var dirs []string
+	for _, name := range entries {
+		if !isFile[name] {
+			dirs = append(dirs, name)
+		}
+	}
+
+	return files, dirs, nil
+}
+
+func (dli *RootFSImage) setMetadata(md metadata.Metadata) error {
 	config, err := dli.image.ConfigFile()
 	if err != nil {
 		return fmt.Errorf("could not find config file in image: %w", err)
 	}
-	md, err := json.Marshal(metadata)
+	labels, err := metadata.EncodeLabels(md, dli.labelOptions)
 	if err != nil {
-		return fmt.Errorf("could not marshal json: %w", err)
-	}
-	if config.Config.Labels == nil {
-		config.Config.Labels = map[string]string{}
+		return err
 	}
 
-	config.Config.Labels["io.deplab.metadata"] = string(md)
+	// the labels of a previous run may be in another format
+	newLabels := map[string]string{}
+	for name, value := range config.Config.Labels {
+		if !metadata.IsLabel(name) {
+			newLabels[name] = value
+		}
+	}
+	for name, value := range labels {
+		newLabels[name] = value
+	}
+	config.Config.Labels = newLabels
 
 	dli.image, err = mutate.Config(dli.image, config.Config)
 	if err != nil {
@@ -77,6 +77,25 @@ func (rfs *RootFS) GetFileContent(path string) (string, error) {
 	return string(fileBytes), nil
 }
 
+// LinkTarget returns the target of the path if it is a symbolic link, as
+// recorded in the image, without following it.
+func (rfs *RootFS) LinkTarget(path string) (string, bool, error) {
+	location := filepath.Join(rfs.rootfsLocation, path)
+	info, err := os.Lstat(location)
+	if err != nil {
+		return "", false, fmt.Errorf("could not find file in rootFS: %w", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return "", false, nil
+	}
+
+	target, err := os.Readlink(location)
+	if err != nil {
+		return "", false, fmt.Errorf("could not read link in rootFS: %w", err)
+	}
+	return target, true, nil
+}
+
 func NewRootFS(image v1.Image, excludePatterns []string) (RootFS, error) {
 	var (
 		rootFS string
@@ -80,6 +80,17 @@ var _ = Describe("rootFS", func() {
 						)))
 			})
 
+			It("returns the target of a symbolic link without following it", func() {
+				target, isLink, err := rfs.LinkTarget("/all-files/symbolic-link-file")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isLink).To(BeTrue())
+				Expect(target).To(Equal("./start-file"))
+
+				_, isLink, err = rfs.LinkTarget("/all-files/start-file")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isLink).To(BeFalse())
+			})
+
 			It("ignores subdirectory in the given directory", func() {
 				statusFiles, err := rfs.GetDirContents("/all-files")
 				Expect(err).ToNot(HaveOccurred())
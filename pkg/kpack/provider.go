@@ -5,12 +5,26 @@ package kpack
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+
 	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/git"
 	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
 	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
 )
 
+// ProjectMetadataLabel is the label kpack sets with the source the image was
+// built from.
+const ProjectMetadataLabel = "io.buildpacks.project.metadata"
+
+const (
+	GitSourceType   = "git"
+	BlobSourceType  = "blob"
+	ImageSourceType = "image"
+)
+
 type RepoSource struct {
 	Source Source `json:"source"`
 }
@@ -21,26 +35,50 @@ type Source struct {
 	Metadata map[string]string `json:"metadata"`
 }
 
-func Provider(dli image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
+// Provider records the source of an image built by kpack. A git source of a
+// repository and commit which are already recorded, e.g. with --git, is not
+// recorded again.
+func Provider(dli image.Image, _ common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
 	var kpackMetadataContents string
 
 	config, err := dli.GetConfig()
 	if err != nil {
-		return metadata.Metadata{}, err
+		return metadata.Metadata{}, nil, err
 	}
 
-	kpackMetadataContents = config.Config.Labels["io.buildpacks.project.metadata"]
+	kpackMetadataContents = config.Config.Labels[ProjectMetadataLabel]
 
 	if kpackMetadataContents != "" {
 		dep, err := parseMetadataJSON(kpackMetadataContents)
 		if err != nil {
-			return metadata.Metadata{}, fmt.Errorf("could not parse kpack metadata: %w", err)
+			var unsupported *UnsupportedSourceError
+			if errors.As(err, &unsupported) {
+				return md, []common.Warning{{
+					Code:    common.UnsupportedKpackSourceWarning,
+					Message: err.Error(),
+				}}, nil
+			}
+			return metadata.Metadata{}, nil, fmt.Errorf("could not parse kpack metadata: %w", err)
+		}
+
+		if dep.Source.Type == metadata.GitSourceType && hasGitSource(md.Dependencies, dep) {
+			return md, nil, nil
 		}
 
 		md.Dependencies = append(md.Dependencies, dep)
 	}
 
-	return md, nil
+	return md, nil, nil
+}
+
+// UnsupportedSourceError is returned for kpack source types deplab does not
+// know how to record.
+type UnsupportedSourceError struct {
+	Type string
+}
+
+func (e *UnsupportedSourceError) Error() string {
+	return fmt.Sprintf("unsupported kpack source type %q", e.Type)
 }
 
 func parseMetadataJSON(kpackMetadata string) (metadata.Dependency, error) {
@@ -51,20 +89,83 @@ func parseMetadataJSON(kpackMetadata string) (metadata.Dependency, error) {
 		return metadata.Dependency{}, fmt.Errorf("could not decode json: %w", err)
 	}
 
-	var kpackMd = metadata.KpackRepoSourceMetadata{
-		Url:  md.Source.Metadata["repository"],
-		Refs: []interface{}{},
+	switch md.Source.Type {
+	case GitSourceType:
+		return metadata.Dependency{
+			Type: "package",
+			Source: metadata.Source{
+				Type: metadata.GitSourceType,
+				Version: map[string]interface{}{
+					"commit": md.Source.Version["commit"],
+				},
+				Metadata: metadata.GitSourceMetadata{
+					URL:  git.NormalizeRemoteURL(md.Source.Metadata["repository"]),
+					Refs: []string{},
+				},
+			},
+		}, nil
+	case BlobSourceType:
+		return metadata.Dependency{
+			Type: "package",
+			Source: metadata.Source{
+				Type: metadata.ArchiveType,
+				Metadata: metadata.ArchiveSourceMetadata{
+					URL: md.Source.Metadata["url"],
+				},
+			},
+		}, nil
+	case ImageSourceType:
+		reference := md.Source.Metadata["image"]
+		version := map[string]interface{}{}
+		if i := strings.Index(reference, "@"); i >= 0 {
+			version["digest"] = reference[i+1:]
+		}
+		return metadata.Dependency{
+			Type: "package",
+			Source: metadata.Source{
+				Type:    metadata.ImageSourceType,
+				Version: version,
+				Metadata: metadata.ImageSourceMetadata{
+					Image: reference,
+					Path:  md.Source.Metadata["path"],
+				},
+			},
+		}, nil
+	default:
+		return metadata.Dependency{}, &UnsupportedSourceError{Type: md.Source.Type}
+	}
+}
+
+// hasGitSource reports whether one of the git dependencies is of the same
+// repository and commit as the git source.
+func hasGitSource(dependencies []metadata.Dependency, source metadata.Dependency) bool {
+	commit, _ := source.Source.Version["commit"].(string)
+	if commit == "" {
+		return false
 	}
+	url := gitURL(source)
 
-	var dep = metadata.Dependency{
-		Type: "package",
-		Source: metadata.Source{
-			Type: "git",
-			Version: map[string]interface{}{
-				"commit": md.Source.Version["commit"],
-			},
-			Metadata: kpackMd,
-		},
+	for _, dependency := range dependencies {
+		if dependency.Source.Type != metadata.GitSourceType {
+			continue
+		}
+		existing, ok := dependency.Source.Version["commit"].(string)
+		if ok && strings.EqualFold(existing, commit) && gitURL(dependency) == url {
+			return true
+		}
+	}
+	return false
+}
+
+// gitURL returns the normalized url of a git dependency, whether its metadata
+// is typed or decoded from an existing label.
+func gitURL(dependency metadata.Dependency) string {
+	var url string
+	switch sourceMetadata := dependency.Source.Metadata.(type) {
+	case metadata.GitSourceMetadata:
+		url = sourceMetadata.URL
+	case map[string]interface{}:
+		url, _ = sourceMetadata["url"].(string)
 	}
-	return dep, nil
+	return git.NormalizeRemoteURL(url)
 }
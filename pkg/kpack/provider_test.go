@@ -58,9 +58,9 @@ var _ = Describe("Kpack", func() {
 		})
 		Context("when the image has kpack label", func() {
 			It("returns properly formatted metadata", func() {
-				expectedMd := metadata.KpackRepoSourceMetadata {
-					Url: "https://github.com/zmackie/github-actions-automate-projects.git",
-					Refs: []interface{}{},
+				expectedMd := metadata.GitSourceMetadata{
+					URL:  "https://github.com/zmackie/github-actions-automate-projects.git",
+					Refs: []string{},
 				}
 				expectedResult := metadata.Dependency{
 					Type:   "package",
@@ -75,19 +75,131 @@ var _ = Describe("Kpack", func() {
 				labels := map[string]string{}
 				labels["io.buildpacks.project.metadata"] = "{\"source\":{\"type\":\"git\",\"version\":{\"commit\":\"1736b5e3b43a8cf40b3640821ee0e26049e1a58c\"},\"metadata\":{\"repository\":\"https://github.com/zmackie/github-actions-automate-projects.git\",\"revision\":\"1736b5e3b43a8cf40b3640821ee0e26049e1a58c\"}}}"
 
-				md, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{})
+				md, _, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{})
 
 				Expect(err).To(Not(HaveOccurred()))
 				Expect(md.Dependencies).To(HaveLen(1))
 				Expect(md.Dependencies[0]).To(Equal(expectedResult))
 			})
 		})
+		Context("when the source is already recorded with --git", func() {
+			It("does not record it twice", func() {
+				gitDependency := metadata.Dependency{
+					Type: "package",
+					Source: metadata.Source{
+						Type:     metadata.GitSourceType,
+						Version:  map[string]interface{}{"commit": "1736b5e3b43a8cf40b3640821ee0e26049e1a58c"},
+						Metadata: metadata.GitSourceMetadata{URL: "https://github.com/zmackie/github-actions-automate-projects.git", Refs: []string{"v1.0.0"}},
+					},
+				}
+				labels := map[string]string{
+					ProjectMetadataLabel: `{"source":{"type":"git","version":{"commit":"1736B5E3B43A8CF40B3640821EE0E26049E1A58C"},"metadata":{"repository":"git@github.com:zmackie/github-actions-automate-projects.git"}}}`,
+				}
+
+				md, _, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{Dependencies: []metadata.Dependency{gitDependency}})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(md.Dependencies).To(Equal([]metadata.Dependency{gitDependency}))
+			})
+
+			It("records a source of another repository at the same commit", func() {
+				gitDependency := metadata.Dependency{
+					Type: "package",
+					Source: metadata.Source{
+						Type:     metadata.GitSourceType,
+						Version:  map[string]interface{}{"commit": "1736b5e3b43a8cf40b3640821ee0e26049e1a58c"},
+						Metadata: metadata.GitSourceMetadata{URL: "https://github.com/org/submodule.git", Refs: []string{}},
+					},
+				}
+				labels := map[string]string{
+					ProjectMetadataLabel: `{"source":{"type":"git","version":{"commit":"1736b5e3b43a8cf40b3640821ee0e26049e1a58c"},"metadata":{"repository":"git@github.com:org/repo.git"}}}`,
+				}
+
+				md, _, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{Dependencies: []metadata.Dependency{gitDependency}})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(md.Dependencies).To(HaveLen(2))
+				Expect(md.Dependencies[1].Source.Metadata.(metadata.GitSourceMetadata).URL).To(Equal("https://github.com/org/repo.git"))
+			})
+
+			It("records a source at another commit", func() {
+				labels := map[string]string{
+					ProjectMetadataLabel: `{"source":{"type":"git","version":{"commit":"0123456789abcdef0123456789abcdef01234567"},"metadata":{"repository":"git@github.com:org/repo.git"}}}`,
+				}
+
+				md, _, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{Dependencies: []metadata.Dependency{{
+					Type:   "package",
+					Source: metadata.Source{Type: metadata.GitSourceType, Version: map[string]interface{}{"commit": "1736b5e3b43a8cf40b3640821ee0e26049e1a58c"}},
+				}}})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(md.Dependencies).To(HaveLen(2))
+				Expect(md.Dependencies[1].Source.Metadata.(metadata.GitSourceMetadata).URL).To(Equal("https://github.com/org/repo.git"))
+			})
+		})
+
+		Context("when the image was built from a blob", func() {
+			It("records an archive dependency", func() {
+				labels := map[string]string{
+					ProjectMetadataLabel: `{"source":{"type":"blob","metadata":{"url":"https://example.com/source.tar.gz"}}}`,
+				}
+
+				md, _, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(md.Dependencies).To(Equal([]metadata.Dependency{{
+					Type: "package",
+					Source: metadata.Source{
+						Type:     metadata.ArchiveType,
+						Metadata: metadata.ArchiveSourceMetadata{URL: "https://example.com/source.tar.gz"},
+					},
+				}}))
+			})
+		})
+
+		Context("when the image was built from an image source", func() {
+			It("records an image dependency with its digest", func() {
+				labels := map[string]string{
+					ProjectMetadataLabel: `{"source":{"type":"image","metadata":{"image":"registry.example.com/org/source@sha256:abc","path":"/app"}}}`,
+				}
+
+				md, _, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(md.Dependencies).To(Equal([]metadata.Dependency{{
+					Type: "package",
+					Source: metadata.Source{
+						Type:     metadata.ImageSourceType,
+						Version:  map[string]interface{}{"digest": "sha256:abc"},
+						Metadata: metadata.ImageSourceMetadata{Image: "registry.example.com/org/source@sha256:abc", Path: "/app"},
+					},
+				}}))
+			})
+		})
+
+		Context("when the image was built from an unsupported source type", func() {
+			It("returns a warning", func() {
+				labels := map[string]string{
+					ProjectMetadataLabel: `{"source":{"type":"bucket","metadata":{}}}`,
+				}
+
+				md, warnings, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{})
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(md.Dependencies).To(BeEmpty())
+				Expect(warnings).To(Equal([]common.Warning{{
+					Code:    common.UnsupportedKpackSourceWarning,
+					Message: `unsupported kpack source type "bucket"`,
+				}}))
+			})
+		})
+
 		Context("when the image has kpack label with malformed data", func() {
 			It("returns generic error message that supported metadata is not present", func() {
 				labels := map[string]string{}
 				labels["io.buildpacks.project.metadata"] = "broken-source\"\"type\":\"git\",\"version\":{\"commit\":\"1736b5e3b43a8cf40b3640821ee0e26049e1a58c\"},\"metadata\":{\"repository\":\"https://github.com/zmackie/github-actions-automate-projects.git\",\"revision\":\"1736b5e3b43a8cf40b3640821ee0e26049e1a58c\"}}}"
 
-				_, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{})
+				_, _, err := Provider(MockImage{labels}, common.RunParams{}, metadata.Metadata{})
 
 				Expect(err).To(MatchError(SatisfyAll(
 					ContainSubstring("could not parse kpack metadata"),
@@ -0,0 +1,225 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DependencyKind declares how the dependencies of a type are merged.
+type DependencyKind struct {
+	// Key identifies a dependency: records of dependencies with the same key
+	// are records of the same dependency.
+	Key func(Dependency) string
+	// Version tells records of the same dependency apart, e.g. the digest of
+	// an archive. Records with different versions conflict.
+	Version func(Dependency) string
+	// FromImage dependencies are generated from the content of the image, so
+	// that they are dropped when the image no longer has them.
+	FromImage bool
+	// Packages returns the versions of the packages of a package list by
+	// name, to report which packages conflict.
+	Packages func(Dependency) map[string]string
+}
+
+// DependencyKinds are the kinds of the inline package lists by dependency
+// type, and of the other dependencies by source type. Dependencies of other
+// types are only identified by their whole content.
+var DependencyKinds = map[string]DependencyKind{
+	DebianPackageListSourceType: {
+		Key:       dependencyType,
+		Version:   versionField("sha256"),
+		FromImage: true,
+		Packages:  packageList("packages", "package", "architecture"),
+	},
+	RPMPackageListSourceType: {
+		Key:       dependencyType,
+		Version:   versionField("sha256"),
+		FromImage: true,
+		Packages:  packageList("packages", "package", "architecture"),
+	},
+	BuildpackMetadataType: {
+		Key:       dependencyType,
+		Version:   versionField("sha256"),
+		FromImage: true,
+		Packages:  packageList("bom", "name", ""),
+	},
+	// a repository may be recorded once per subdirectory or submodule
+	GitSourceType: {
+		Key: keyOf(metadataField("url"), versionField("commit"), metadataField("subdirectory"), metadataField("submodule_path")),
+	},
+	ArchiveType: {
+		Key:     keyOf(metadataField("url")),
+		Version: firstOf(metadataField("sha256"), metadataField("sha512")),
+	},
+	FileSourceType: {
+		Key:     keyOf(metadataField("path")),
+		Version: filesDigest,
+	},
+	HgSourceType: {
+		Key: keyOf(metadataField("url"), versionField("changeset")),
+	},
+	SvnSourceType: {
+		Key: keyOf(metadataField("url"), versionField("revision")),
+	},
+	GoModuleSourceType: {
+		Key:     keyOf(metadataField("path"), versionField("version")),
+		Version: metadataField("hash"),
+	},
+	ImageSourceType: {
+		Key:     imageName,
+		Version: versionField("digest"),
+	},
+}
+
+// KindOf returns the kind of the dependency.
+func KindOf(dependency Dependency) DependencyKind {
+	if kind, ok := DependencyKinds[dependency.Type]; ok {
+		return kind
+	}
+	if kind, ok := DependencyKinds[dependency.Source.Type]; ok && dependency.Type == PackageType {
+		return kind
+	}
+	return DependencyKind{}
+}
+
+// DependencyKey returns the key identifying the dependency among the
+// dependencies of all types. Dependencies without a key are identified by
+// their whole content, so that only exact duplicates have the same key.
+func DependencyKey(dependency Dependency) string {
+	kind := KindOf(dependency)
+
+	key := ""
+	if kind.Key != nil {
+		key = kind.Key(dependency)
+	}
+	if key == "" {
+		content, _ := json.Marshal(generic(dependency))
+		key = string(content)
+	}
+
+	return dependencyTypeName(dependency) + ":" + key
+}
+
+// dependencyTypeName is the type of inline package lists and the source
+// type of the other dependencies.
+func dependencyTypeName(dependency Dependency) string {
+	if dependency.Type == PackageType && dependency.Source.Type != "" {
+		return dependency.Source.Type
+	}
+	return dependency.Type
+}
+
+func dependencyType(dependency Dependency) string {
+	return dependency.Type
+}
+
+func versionField(name string) func(Dependency) string {
+	return func(dependency Dependency) string {
+		value, ok := dependency.Source.Version[name]
+		if !ok || value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+}
+
+func metadataField(name string) func(Dependency) string {
+	return func(dependency Dependency) string {
+		fields, _ := generic(dependency.Source.Metadata).(map[string]interface{})
+		value, _ := fields[name].(string)
+		return value
+	}
+}
+
+// keyOf joins the fields, or returns an empty key when all are empty.
+func keyOf(fields ...func(Dependency) string) func(Dependency) string {
+	return func(dependency Dependency) string {
+		var values []string
+		empty := true
+		for _, field := range fields {
+			value := field(dependency)
+			empty = empty && value == ""
+			values = append(values, value)
+		}
+		if empty {
+			return ""
+		}
+		return strings.Join(values, "@")
+	}
+}
+
+// firstOf returns the first non empty field.
+func firstOf(fields ...func(Dependency) string) func(Dependency) string {
+	return func(dependency Dependency) string {
+		for _, field := range fields {
+			if value := field(dependency); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+}
+
+func imageName(dependency Dependency) string {
+	image := metadataField("image")(dependency)
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	return image
+}
+
+func filesDigest(dependency Dependency) string {
+	fields, _ := generic(dependency.Source.Metadata).(map[string]interface{})
+	files, _ := fields["files"].([]interface{})
+
+	var digests []string
+	for _, file := range files {
+		fileFields, _ := file.(map[string]interface{})
+		if target, ok := fileFields["link_target"]; ok {
+			digests = append(digests, fmt.Sprintf("%v->%v", fileFields["path"], target))
+			continue
+		}
+		digests = append(digests, fmt.Sprintf("%v=%v", fileFields["path"], fileFields["sha256"]))
+	}
+	return strings.Join(digests, ",")
+}
+
+// packageList returns the versions of the packages of the list field of the
+// source metadata, named after their name field and architecture, if any.
+func packageList(listField, nameField, architectureField string) func(Dependency) map[string]string {
+	return func(dependency Dependency) map[string]string {
+		fields, _ := generic(dependency.Source.Metadata).(map[string]interface{})
+		list, _ := fields[listField].([]interface{})
+
+		packages := map[string]string{}
+		for _, entry := range list {
+			packageFields, _ := entry.(map[string]interface{})
+			name, _ := packageFields[nameField].(string)
+			if architecture, _ := packageFields[architectureField].(string); architecture != "" {
+				name += ":" + architecture
+			}
+			version, _ := packageFields["version"].(string)
+			packages[name] = version
+		}
+		return packages
+	}
+}
+
+// generic returns the value as decoded from json, as the metadata of
+// existing labels is, so that typed and decoded metadata compare alike.
+func generic(value interface{}) interface{} {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
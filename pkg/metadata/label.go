@@ -0,0 +1,206 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package metadata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	Label       = "io.deplab.metadata"
+	LegacyLabel = "io.pivotal.metadata"
+
+	// LabelEncodingLabel is the format of the metadata labels, when not json.
+	LabelEncodingLabel = Label + ".encoding"
+	// LabelPartsLabel is the number of labels the metadata is split across.
+	LabelPartsLabel = Label + ".parts"
+	// LabelDigestLabel is the digest of the json metadata stored elsewhere.
+	LabelDigestLabel = Label + ".digest"
+	// LabelReferenceLabel is where the json metadata is stored.
+	LabelReferenceLabel = Label + ".reference"
+)
+
+const (
+	// JSONLabelFormat stores the json metadata in the io.deplab.metadata
+	// label.
+	JSONLabelFormat = "json"
+	// GzipLabelFormat stores the gzipped json metadata, base64 encoded, in
+	// the io.deplab.metadata label.
+	GzipLabelFormat = "gzip"
+	// SplitLabelFormat stores the json metadata across the numbered labels
+	// io.deplab.metadata.1 to io.deplab.metadata.<parts>.
+	SplitLabelFormat = "split"
+	// ReferenceLabelFormat only stores the digest of the json metadata and a
+	// reference to where it is stored.
+	ReferenceLabelFormat = "reference"
+)
+
+var LabelFormats = []string{JSONLabelFormat, GzipLabelFormat, SplitLabelFormat, ReferenceLabelFormat}
+
+// DefaultLabelPartSize is the maximum size in bytes of each label with the
+// split format.
+const DefaultLabelPartSize = 32 * 1024
+
+type LabelOptions struct {
+	Format string
+	// PartSize is the maximum size of each label with the split format.
+	PartSize int
+	// Reference is where the metadata is stored with the reference format.
+	Reference string
+}
+
+// LabelResolver returns the content stored at a reference of the reference
+// label format.
+type LabelResolver func(reference string) ([]byte, error)
+
+// IsLabel reports whether the label is one of the labels of the metadata.
+func IsLabel(name string) bool {
+	return name == Label || strings.HasPrefix(name, Label+".")
+}
+
+// EncodeLabels returns the labels storing the metadata in the given format.
+func EncodeLabels(md Metadata, options LabelOptions) (map[string]string, error) {
+	content, err := json.Marshal(md)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal json: %w", err)
+	}
+
+	switch options.Format {
+	case JSONLabelFormat, "":
+		return map[string]string{Label: string(content)}, nil
+	case GzipLabelFormat:
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+		if _, err := writer.Write(content); err != nil {
+			return nil, fmt.Errorf("could not compress metadata: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("could not compress metadata: %w", err)
+		}
+		return map[string]string{
+			Label:              base64.StdEncoding.EncodeToString(buffer.Bytes()),
+			LabelEncodingLabel: GzipLabelFormat,
+		}, nil
+	case SplitLabelFormat:
+		parts := splitLabel(content, options.PartSize)
+		labels := map[string]string{
+			LabelEncodingLabel: SplitLabelFormat,
+			LabelPartsLabel:    strconv.Itoa(len(parts)),
+		}
+		for i, part := range parts {
+			labels[partLabel(i+1)] = part
+		}
+		return labels, nil
+	case ReferenceLabelFormat:
+		if options.Reference == "" {
+			return nil, fmt.Errorf("the %s label format requires a reference to the stored metadata", ReferenceLabelFormat)
+		}
+		return map[string]string{
+			LabelEncodingLabel:  ReferenceLabelFormat,
+			LabelDigestLabel:    contentDigest(content),
+			LabelReferenceLabel: options.Reference,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported label format %s, supported formats are: %s", options.Format, strings.Join(LabelFormats, ", "))
+	}
+}
+
+// DecodeLabels returns the json metadata stored in the labels, whatever their
+// format, and whether the labels have metadata. Metadata stored at a
+// reference is read with resolve, and must match its digest.
+func DecodeLabels(labels map[string]string, resolve LabelResolver) ([]byte, bool, error) {
+	switch labels[LabelEncodingLabel] {
+	case "", JSONLabelFormat:
+		label, ok := labels[Label]
+		return []byte(label), ok, nil
+	case GzipLabelFormat:
+		compressed, err := base64.StdEncoding.DecodeString(labels[Label])
+		if err != nil {
+			return nil, true, fmt.Errorf("could not decode %s label: %w", Label, err)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, true, fmt.Errorf("could not decompress %s label: %w", Label, err)
+		}
+		defer reader.Close()
+		content, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, true, fmt.Errorf("could not decompress %s label: %w", Label, err)
+		}
+		return content, true, nil
+	case SplitLabelFormat:
+		parts, err := strconv.Atoi(labels[LabelPartsLabel])
+		if err != nil || parts < 1 {
+			return nil, true, fmt.Errorf("invalid %s label %q", LabelPartsLabel, labels[LabelPartsLabel])
+		}
+		var builder strings.Builder
+		for i := 1; i <= parts; i++ {
+			part, ok := labels[partLabel(i)]
+			if !ok {
+				return nil, true, fmt.Errorf("missing %s label", partLabel(i))
+			}
+			builder.WriteString(part)
+		}
+		return []byte(builder.String()), true, nil
+	case ReferenceLabelFormat:
+		reference := labels[LabelReferenceLabel]
+		if reference == "" {
+			return nil, true, fmt.Errorf("missing %s label", LabelReferenceLabel)
+		}
+		if resolve == nil {
+			return nil, true, fmt.Errorf("cannot read metadata stored at %s", reference)
+		}
+		content, err := resolve(reference)
+		if err != nil {
+			return nil, true, fmt.Errorf("could not read metadata stored at %s: %w", reference, err)
+		}
+		if digest := contentDigest(content); digest != labels[LabelDigestLabel] {
+			return nil, true, fmt.Errorf("metadata stored at %s has digest %s, expected %s", reference, digest, labels[LabelDigestLabel])
+		}
+		return content, true, nil
+	default:
+		return nil, true, fmt.Errorf("unsupported %s label %q", LabelEncodingLabel, labels[LabelEncodingLabel])
+	}
+}
+
+func partLabel(i int) string {
+	return Label + "." + strconv.Itoa(i)
+}
+
+// splitLabel splits the content in parts of at most size bytes, without
+// splitting a character across parts.
+func splitLabel(content []byte, size int) []string {
+	if size <= 0 {
+		size = DefaultLabelPartSize
+	}
+
+	var parts []string
+	for len(content) > size {
+		end := size
+		for end > 0 && !utf8.RuneStart(content[end]) {
+			end--
+		}
+		if end == 0 {
+			end = size
+		}
+		parts = append(parts, string(content[:end]))
+		content = content[end:]
+	}
+	return append(parts, string(content))
+}
+
+func contentDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
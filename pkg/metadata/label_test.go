@@ -0,0 +1,153 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package metadata_test
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/test/test_utils"
+)
+
+var _ = Describe("labels", func() {
+	var content []byte
+
+	BeforeEach(func() {
+		var err error
+		content, err = json.Marshal(test_utils.MetadataSample)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	resolveTo := func(stored []byte) LabelResolver {
+		return func(reference string) ([]byte, error) {
+			Expect(reference).To(Equal("oci:/sboms@sha256:sbom"))
+			return stored, nil
+		}
+	}
+
+	DescribeTable("EncodeLabels and DecodeLabels round trip", func(options LabelOptions) {
+		labels, err := EncodeLabels(test_utils.MetadataSample, options)
+		Expect(err).ToNot(HaveOccurred())
+
+		for name := range labels {
+			Expect(IsLabel(name)).To(BeTrue())
+		}
+
+		decoded, ok, err := DecodeLabels(labels, resolveTo(content))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(decoded).To(Equal(content))
+	},
+		Entry("json", LabelOptions{Format: JSONLabelFormat}),
+		Entry("default", LabelOptions{}),
+		Entry("gzip", LabelOptions{Format: GzipLabelFormat}),
+		Entry("split", LabelOptions{Format: SplitLabelFormat, PartSize: 100}),
+		Entry("split in a single part", LabelOptions{Format: SplitLabelFormat}),
+		Entry("reference", LabelOptions{Format: ReferenceLabelFormat, Reference: "oci:/sboms@sha256:sbom"}),
+	)
+
+	Describe("EncodeLabels", func() {
+		It("stores the json metadata in a single label by default", func() {
+			labels, err := EncodeLabels(test_utils.MetadataSample, LabelOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(labels).To(Equal(map[string]string{Label: string(content)}))
+		})
+
+		It("compresses the metadata label", func() {
+			labels, err := EncodeLabels(test_utils.MetadataSample, LabelOptions{Format: GzipLabelFormat})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(labels).To(HaveKeyWithValue(LabelEncodingLabel, GzipLabelFormat))
+			Expect(len(labels[Label])).To(BeNumerically("<", len(content)))
+		})
+
+		It("splits the metadata across numbered labels of the part size", func() {
+			labels, err := EncodeLabels(test_utils.MetadataSample, LabelOptions{Format: SplitLabelFormat, PartSize: 100})
+			Expect(err).ToNot(HaveOccurred())
+
+			parts := (len(content) + 99) / 100
+			Expect(labels).To(HaveKeyWithValue(LabelEncodingLabel, SplitLabelFormat))
+			Expect(labels).To(HaveKeyWithValue(LabelPartsLabel, fmt.Sprint(parts)))
+			Expect(labels).To(HaveLen(parts + 2))
+			for i := 1; i <= parts; i++ {
+				Expect(len(labels[fmt.Sprintf("%s.%d", Label, i)])).To(BeNumerically("<=", 100))
+			}
+		})
+
+		It("does not split characters across labels", func() {
+			md := Metadata{Base: Base{"name": strings.Repeat("é", 100)}}
+
+			labels, err := EncodeLabels(md, LabelOptions{Format: SplitLabelFormat, PartSize: 7})
+			Expect(err).ToNot(HaveOccurred())
+
+			for name, value := range labels {
+				Expect(utf8.ValidString(value)).To(BeTrue(), name)
+			}
+		})
+
+		It("stores the digest of the metadata and its reference", func() {
+			labels, err := EncodeLabels(test_utils.MetadataSample, LabelOptions{Format: ReferenceLabelFormat, Reference: "oci:/sboms@sha256:sbom"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(labels).To(Equal(map[string]string{
+				LabelEncodingLabel:  ReferenceLabelFormat,
+				LabelDigestLabel:    fmt.Sprintf("sha256:%x", sha256.Sum256(content)),
+				LabelReferenceLabel: "oci:/sboms@sha256:sbom",
+			}))
+		})
+
+		It("returns an error for the reference format without a reference", func() {
+			_, err := EncodeLabels(test_utils.MetadataSample, LabelOptions{Format: ReferenceLabelFormat})
+			Expect(err).To(MatchError(ContainSubstring("requires a reference")))
+		})
+
+		It("returns an error for an unknown format", func() {
+			_, err := EncodeLabels(test_utils.MetadataSample, LabelOptions{Format: "zip"})
+			Expect(err).To(MatchError(ContainSubstring("unsupported label format zip")))
+		})
+	})
+
+	Describe("DecodeLabels", func() {
+		It("reports when there is no metadata label", func() {
+			_, ok, err := DecodeLabels(map[string]string{"other": "label"}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		It("returns an error when a part is missing", func() {
+			labels, err := EncodeLabels(test_utils.MetadataSample, LabelOptions{Format: SplitLabelFormat, PartSize: 100})
+			Expect(err).ToNot(HaveOccurred())
+			delete(labels, Label+".2")
+
+			_, _, err = DecodeLabels(labels, nil)
+			Expect(err).To(MatchError(ContainSubstring("missing io.deplab.metadata.2 label")))
+		})
+
+		It("returns an error when the compressed label is invalid", func() {
+			_, _, err := DecodeLabels(map[string]string{Label: "not base64!", LabelEncodingLabel: GzipLabelFormat}, nil)
+			Expect(err).To(MatchError(ContainSubstring("could not decode io.deplab.metadata label")))
+		})
+
+		It("returns an error when the referenced metadata does not match the digest", func() {
+			labels, err := EncodeLabels(test_utils.MetadataSample, LabelOptions{Format: ReferenceLabelFormat, Reference: "oci:/sboms@sha256:sbom"})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = DecodeLabels(labels, resolveTo([]byte(`{"tampered":true}`)))
+			Expect(err).To(MatchError(ContainSubstring("metadata stored at oci:/sboms@sha256:sbom has digest")))
+		})
+
+		It("returns an error for an unknown encoding", func() {
+			_, _, err := DecodeLabels(map[string]string{Label: "{}", LabelEncodingLabel: "zip"}, nil)
+			Expect(err).To(MatchError(ContainSubstring(`unsupported io.deplab.metadata.encoding label "zip"`)))
+		})
+	})
+})
@@ -4,55 +4,232 @@
 package metadata
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 type Warning string
 
+// Precedence decides which of the original and current records of a
+// dependency is kept when they conflict.
+type Precedence string
+
+const (
+	PreferCurrent  Precedence = "prefer-current"
+	PreferOriginal Precedence = "prefer-original"
+	// FailOnConflict fails the merge when the records conflict.
+	FailOnConflict Precedence = "fail"
+)
+
+var Precedences = []string{string(PreferCurrent), string(PreferOriginal), string(FailOnConflict)}
+
+// Conflict is a dependency, or a package of a package list, with different
+// versions in the original and the current metadata. An empty version is
+// missing from that metadata.
+type Conflict struct {
+	DependencyType string
+	Name           string
+	Original       string
+	Current        string
+}
+
+// Merge merges the metadata, with the current metadata taking precedence.
 func Merge(original, current Metadata) (Metadata, []Warning) {
-	var warnings []Warning
-	newDependencies := make([]Dependency, 0)
+	merged, warnings, _, _ := MergeWithPrecedence(original, current, PreferCurrent)
+	return merged, warnings
+}
+
+// MergeWithPrecedence merges the original metadata, e.g. of an existing
+// label, with the current metadata. Dependencies are deduplicated by their
+// key, and the types of the conflicting records are returned as warnings,
+// with the conflicting packages.
+//
+// Dependencies generated from the image only in the original metadata
+// conflict as well, as the image no longer has them, whereas other
+// dependencies only in the original metadata are kept. Records which agree
+// are taken from the current metadata for dependencies generated from the
+// image, and from the original metadata otherwise, as they may hold more of
+// what was given when labeling.
+func MergeWithPrecedence(original, current Metadata, precedence Precedence) (Metadata, []Warning, []Conflict, error) {
+	var (
+		warnings  []Warning
+		conflicts []Conflict
+	)
 
-	if len(original.Base) > 0 && !reflect.DeepEqual(original.Base, current.Base) {
+	base := current.Base
+	if len(original.Base) > 0 && !reflect.DeepEqual(withoutDerivedKeys(original.Base), withoutDerivedKeys(current.Base)) {
 		warnings = append(warnings, "base")
+		if precedence == PreferOriginal {
+			base = original.Base
+		}
+	} else if len(original.Base) > 0 {
+		// the derived keys of the original base, e.g. the base image given
+		// when labeling, are kept unless the current base has them too
+		base = MergeBase(original.Base, current.Base)
+	}
+
+	originalDependencies := indexDependencies(original.Dependencies)
+	currentDependencies := indexDependencies(current.Dependencies)
+	newDependencies := make([]Dependency, 0)
+
+	for _, key := range currentDependencies.keys {
+		currentDependency := currentDependencies.byKey[key]
+		kind := KindOf(currentDependency)
+
+		originalDependency, inOriginal := originalDependencies.byKey[key]
+		if !inOriginal {
+			newDependencies = append(newDependencies, currentDependency)
+			continue
+		}
+
+		if isConflict(kind, originalDependency, currentDependency) {
+			warnings = appendWarning(warnings, Warning(dependencyTypeName(currentDependency)))
+			conflicts = append(conflicts, conflictsOf(kind, originalDependency, currentDependency)...)
+			if precedence == PreferOriginal {
+				newDependencies = append(newDependencies, originalDependency)
+			} else {
+				newDependencies = append(newDependencies, currentDependency)
+			}
+		} else if kind.FromImage {
+			newDependencies = append(newDependencies, currentDependency)
+		} else {
+			newDependencies = append(newDependencies, originalDependency)
+		}
 	}
 
-	newDependencies, warnings = selectAdditionalDependencies(DebianPackageListSourceType, newDependencies, warnings, original, current)
-	newDependencies, warnings = selectAdditionalDependencies(RPMPackageListSourceType, newDependencies, warnings, original, current)
-	newDependencies, warnings = selectAdditionalDependencies(BuildpackMetadataType, newDependencies, warnings, original, current)
-	newDependencies, warnings = selectAdditionalDependencies(PackageType, newDependencies, warnings, original, current)
+	for _, key := range originalDependencies.keys {
+		if _, inCurrent := currentDependencies.byKey[key]; inCurrent {
+			continue
+		}
+
+		originalDependency := originalDependencies.byKey[key]
+		if KindOf(originalDependency).FromImage {
+			warnings = appendWarning(warnings, Warning(dependencyTypeName(originalDependency)))
+			if precedence != PreferOriginal {
+				continue
+			}
+		}
+		newDependencies = append(newDependencies, originalDependency)
+	}
 
-	for _, dep := range original.Dependencies {
-		if dep.Source.Type == GitSourceType {
-			newDependencies = append(newDependencies, dep)
-		} else if dep.Source.Type == ArchiveType {
-			newDependencies = append(newDependencies, dep)
+	if precedence == FailOnConflict && len(warnings) > 0 {
+		var differences []string
+		for _, warning := range warnings {
+			differences = append(differences, string(warning))
 		}
+		return Metadata{}, warnings, conflicts, fmt.Errorf("original and current metadata differ in: %s", strings.Join(differences, ", "))
 	}
 
 	return Metadata{
 		Provenance:   append(original.Provenance, current.Provenance...),
-		Base:         current.Base,
+		Base:         base,
 		Dependencies: newDependencies,
-	}, warnings
+	}, warnings, conflicts, nil
+}
+
+// MergeBase returns a copy of the base with the entries of the other base
+// added, replacing the ones with the same key.
+func MergeBase(base, other Base) Base {
+	merged := Base{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+	return merged
+}
+
+// withoutDerivedKeys returns a copy of the base without the DerivedBaseKeys.
+func withoutDerivedKeys(base Base) Base {
+	stripped := MergeBase(base, nil)
+	for _, key := range DerivedBaseKeys {
+		delete(stripped, key)
+	}
+	return stripped
+}
+
+type dependencyIndex struct {
+	keys  []string
+	byKey map[string]Dependency
+}
+
+// indexDependencies indexes the dependencies by key, in order, keeping the
+// first of duplicate records.
+func indexDependencies(dependencies []Dependency) dependencyIndex {
+	index := dependencyIndex{byKey: map[string]Dependency{}}
+	for _, dependency := range dependencies {
+		key := DependencyKey(dependency)
+		if _, ok := index.byKey[key]; ok {
+			continue
+		}
+		index.keys = append(index.keys, key)
+		index.byKey[key] = dependency
+	}
+	return index
 }
 
-func selectAdditionalDependencies(sourceType string, dependencies []Dependency, warnings []Warning, original Metadata, current Metadata) ([]Dependency, []Warning) {
-	originalDependency, presentInSource := SelectDependency(original.Dependencies, sourceType)
-	currentDependency, presentInAdditional := SelectDependency(current.Dependencies, sourceType)
+func isConflict(kind DependencyKind, original, current Dependency) bool {
+	if kind.Version == nil {
+		return false
+	}
+	return kind.Version(original) != kind.Version(current)
+}
+
+// conflictsOf returns the conflicting packages of package lists, or the
+// conflicting dependency otherwise.
+func conflictsOf(kind DependencyKind, original, current Dependency) []Conflict {
+	dependencyType := dependencyTypeName(current)
+
+	if kind.Packages == nil {
+		return []Conflict{{
+			DependencyType: dependencyType,
+			Name:           kind.Key(current),
+			Original:       kind.Version(original),
+			Current:        kind.Version(current),
+		}}
+	}
+
+	originalPackages := kind.Packages(original)
+	currentPackages := kind.Packages(current)
 
-	if presentInSource &&
-		// it's enough to check the property sha256 of the version because all 3 dependency are using this fields
-		// as digest of the serialized version of the Metadata Property
-		originalDependency.Source.Version["sha256"] != currentDependency.Source.Version["sha256"] {
-		warnings = append(warnings, Warning(sourceType))
+	var names []string
+	for name := range originalPackages {
+		names = append(names, name)
 	}
+	for name := range currentPackages {
+		if _, ok := originalPackages[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
 
-	if presentInAdditional {
-		dependencies = append(dependencies, currentDependency)
+	var conflicts []Conflict
+	for _, name := range names {
+		originalVersion, inOriginal := originalPackages[name]
+		currentVersion, inCurrent := currentPackages[name]
+		if inOriginal && inCurrent && originalVersion == currentVersion {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{
+			DependencyType: dependencyType,
+			Name:           name,
+			Original:       originalVersion,
+			Current:        currentVersion,
+		})
 	}
+	return conflicts
+}
 
-	return dependencies, warnings
+func appendWarning(warnings []Warning, warning Warning) []Warning {
+	for _, w := range warnings {
+		if w == warning {
+			return warnings
+		}
+	}
+	return append(warnings, warning)
 }
 
 func SelectDependency(dependencies []Dependency, dependencyType string) (Dependency, bool) {
@@ -103,12 +103,61 @@ var _ = Describe("Merge", func() {
 					Expect(warnings).To(ConsistOf(metadata.Warning("base")))
 				})
 			})
+
+			Context("when the original base has the base image given when labeling", func() {
+				It("keeps it without a warning", func() {
+					original := metadata.Metadata{
+						Base: metadata.Base{
+							"name":                      "ubuntu",
+							metadata.BaseImageKey:       "registry.example.com/base:1.0",
+							metadata.BaseImageDigestKey: "sha256:abc",
+							metadata.BaseImageSourceKey: "layers",
+						},
+					}
+					current := metadata.Metadata{
+						Base: metadata.Base{"name": "ubuntu"},
+					}
+
+					result, warnings := metadata.Merge(original, current)
+					Expect(result.Base).To(Equal(original.Base))
+					Expect(warnings).To(BeEmpty())
+				})
+			})
+
+			Context("when original and current only differ in derived keys", func() {
+				It("keeps the derived keys of both without a warning", func() {
+					original := metadata.Metadata{
+						Base: metadata.Base{
+							"name":                     "ubuntu",
+							metadata.BaseDistrolessKey: "true",
+						},
+					}
+					current := metadata.Metadata{
+						Base: metadata.Base{
+							"name":                               "ubuntu",
+							metadata.BaseEvidenceKey:             "/etc/os-release",
+							metadata.BaseConfidenceKey:           "high",
+							metadata.BaseEndOfStandardSupportKey: "2023-05-31",
+						},
+					}
+
+					result, warnings := metadata.Merge(original, current)
+					Expect(result.Base).To(Equal(metadata.Base{
+						"name":                               "ubuntu",
+						metadata.BaseDistrolessKey:           "true",
+						metadata.BaseEvidenceKey:             "/etc/os-release",
+						metadata.BaseConfidenceKey:           "high",
+						metadata.BaseEndOfStandardSupportKey: "2023-05-31",
+					}))
+					Expect(warnings).To(BeEmpty())
+				})
+			})
 		})
 	})
 
 	Describe("git", func() {
 		Context("git dependencies on original", func() {
-			It("retains the git dependencies from the original metadata", func() {
+			It("retains the git dependencies from the original metadata, without duplicates", func() {
 				originalGit := metadata.Dependency{
 					Type: metadata.PackageType,
 					Source: metadata.Source{
@@ -127,7 +176,7 @@ var _ = Describe("Merge", func() {
 				}
 
 				result, warnings := metadata.Merge(original, current)
-				Expect(result.Dependencies).To(Equal([]metadata.Dependency{originalGit, originalGit}))
+				Expect(result.Dependencies).To(Equal([]metadata.Dependency{originalGit}))
 				Expect(warnings).To(BeEmpty())
 			})
 		})
@@ -378,7 +427,7 @@ var _ = Describe("Merge", func() {
 
 	Describe("archive", func() {
 		Context("archive dependencies on original", func() {
-			It("retains the archives dependencies from the original metadata", func() {
+			It("retains the archives dependencies from the original metadata, without duplicates", func() {
 				originalArchive := metadata.Dependency{
 					Type: metadata.PackageType,
 					Source: metadata.Source{
@@ -397,7 +446,7 @@ var _ = Describe("Merge", func() {
 				}
 
 				result, warnings := metadata.Merge(original, current)
-				Expect(result.Dependencies).To(Equal([]metadata.Dependency{originalArchive, originalArchive}))
+				Expect(result.Dependencies).To(Equal([]metadata.Dependency{originalArchive}))
 				Expect(warnings).To(BeEmpty())
 			})
 		})
@@ -595,6 +644,165 @@ var _ = Describe("Merge", func() {
 			))
 		})
 	})
+
+	Describe("dependencies in both original and current", func() {
+		gitDependency := func(url, commit string) metadata.Dependency {
+			return metadata.Dependency{
+				Type: metadata.PackageType,
+				Source: metadata.Source{
+					Type:     metadata.GitSourceType,
+					Version:  map[string]interface{}{"commit": commit},
+					Metadata: metadata.GitSourceMetadata{URL: url, Refs: []string{}},
+				},
+			}
+		}
+
+		dpkgDependency := func(sha256 string, packages ...metadata.DpkgPackage) metadata.Dependency {
+			return metadata.Dependency{
+				Type: metadata.DebianPackageListSourceType,
+				Source: metadata.Source{
+					Type:     "inline",
+					Version:  map[string]interface{}{"sha256": sha256},
+					Metadata: metadata.DebianPackageListSourceMetadata{Packages: packages},
+				},
+			}
+		}
+
+		archiveDependency := func(url, sha256 string) metadata.Dependency {
+			return metadata.Dependency{
+				Type: metadata.PackageType,
+				Source: metadata.Source{
+					Type:     metadata.ArchiveType,
+					Metadata: metadata.ArchiveSourceMetadata{URL: url, Sha256: sha256},
+				},
+			}
+		}
+
+		It("records a git dependency once by url and commit", func() {
+			originalGit := serializeAndDeserializeDependency(gitDependency("https://example.com/repo.git", "commit1"))
+			otherGit := gitDependency("https://example.com/repo.git", "commit2")
+
+			result, warnings := metadata.Merge(metadata.Metadata{
+				Dependencies: []metadata.Dependency{originalGit},
+			}, metadata.Metadata{
+				Dependencies: []metadata.Dependency{gitDependency("https://example.com/repo.git", "commit1"), otherGit},
+			})
+
+			Expect(warnings).To(BeEmpty())
+			Expect(result.Dependencies).To(Equal([]metadata.Dependency{originalGit, otherGit}))
+		})
+
+		It("retains dependencies of types it does not know", func() {
+			originalUnknown := metadata.Dependency{Type: "new_type", Source: metadata.Source{Type: "inline"}}
+			currentUnknown := metadata.Dependency{Type: metadata.PackageType, Source: metadata.Source{Type: "new_source"}}
+
+			result, warnings := metadata.Merge(metadata.Metadata{
+				Dependencies: []metadata.Dependency{originalUnknown, originalUnknown},
+			}, metadata.Metadata{
+				Dependencies: []metadata.Dependency{currentUnknown},
+			})
+
+			Expect(warnings).To(BeEmpty())
+			Expect(result.Dependencies).To(Equal([]metadata.Dependency{currentUnknown, originalUnknown}))
+		})
+
+		It("reports the conflicting packages of package lists", func() {
+			originalDpkg := serializeAndDeserializeDependency(dpkgDependency("original",
+				metadata.DpkgPackage{Package: "openssl", Version: "1.1.1", Architecture: "amd64"},
+				metadata.DpkgPackage{Package: "zlib", Version: "1.2.11", Architecture: "amd64"},
+				metadata.DpkgPackage{Package: "removed", Version: "1", Architecture: "all"},
+			))
+			currentDpkg := dpkgDependency("current",
+				metadata.DpkgPackage{Package: "openssl", Version: "1.1.2", Architecture: "amd64"},
+				metadata.DpkgPackage{Package: "zlib", Version: "1.2.11", Architecture: "amd64"},
+				metadata.DpkgPackage{Package: "added", Version: "2", Architecture: "all"},
+			)
+
+			result, warnings, conflicts, err := metadata.MergeWithPrecedence(metadata.Metadata{
+				Dependencies: []metadata.Dependency{originalDpkg},
+			}, metadata.Metadata{
+				Dependencies: []metadata.Dependency{currentDpkg},
+			}, metadata.PreferCurrent)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(warnings).To(ConsistOf(metadata.Warning(metadata.DebianPackageListSourceType)))
+			Expect(conflicts).To(Equal([]metadata.Conflict{
+				{DependencyType: metadata.DebianPackageListSourceType, Name: "added:all", Current: "2"},
+				{DependencyType: metadata.DebianPackageListSourceType, Name: "openssl:amd64", Original: "1.1.1", Current: "1.1.2"},
+				{DependencyType: metadata.DebianPackageListSourceType, Name: "removed:all", Original: "1"},
+			}))
+			Expect(result.Dependencies).To(Equal([]metadata.Dependency{currentDpkg}))
+		})
+
+		It("reports an archive with a different digest", func() {
+			_, warnings, conflicts, err := metadata.MergeWithPrecedence(metadata.Metadata{
+				Dependencies: []metadata.Dependency{archiveDependency("https://example.com/a.tgz", "original")},
+			}, metadata.Metadata{
+				Dependencies: []metadata.Dependency{archiveDependency("https://example.com/a.tgz", "current")},
+			}, metadata.PreferCurrent)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(warnings).To(ConsistOf(metadata.Warning(metadata.ArchiveType)))
+			Expect(conflicts).To(Equal([]metadata.Conflict{
+				{DependencyType: metadata.ArchiveType, Name: "https://example.com/a.tgz", Original: "original", Current: "current"},
+			}))
+		})
+
+		Context("with precedence to the original metadata", func() {
+			It("keeps the original records of conflicting dependencies", func() {
+				originalDpkg := dpkgDependency("original")
+				originalArchive := archiveDependency("https://example.com/a.tgz", "original")
+				originalRpm := metadata.Dependency{
+					Type:   metadata.RPMPackageListSourceType,
+					Source: metadata.Source{Type: "inline", Version: map[string]interface{}{"sha256": "original"}},
+				}
+
+				result, warnings, _, err := metadata.MergeWithPrecedence(metadata.Metadata{
+					Base:         metadata.Base{"name": "original"},
+					Dependencies: []metadata.Dependency{originalDpkg, originalArchive, originalRpm},
+				}, metadata.Metadata{
+					Base:         metadata.Base{"name": "current"},
+					Dependencies: []metadata.Dependency{dpkgDependency("current"), archiveDependency("https://example.com/a.tgz", "current")},
+				}, metadata.PreferOriginal)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(warnings).To(ConsistOf(
+					metadata.Warning("base"),
+					metadata.Warning(metadata.DebianPackageListSourceType),
+					metadata.Warning(metadata.ArchiveType),
+					metadata.Warning(metadata.RPMPackageListSourceType),
+				))
+				Expect(result.Base).To(Equal(metadata.Base{"name": "original"}))
+				Expect(result.Dependencies).To(Equal([]metadata.Dependency{originalDpkg, originalArchive, originalRpm}))
+			})
+		})
+
+		Context("when failing on conflicts", func() {
+			It("returns an error when the metadata conflict", func() {
+				_, _, _, err := metadata.MergeWithPrecedence(metadata.Metadata{
+					Dependencies: []metadata.Dependency{dpkgDependency("original")},
+				}, metadata.Metadata{
+					Dependencies: []metadata.Dependency{dpkgDependency("current")},
+				}, metadata.FailOnConflict)
+
+				Expect(err).To(MatchError("original and current metadata differ in: debian_package_list"))
+			})
+
+			It("merges metadata which agree", func() {
+				git := gitDependency("https://example.com/repo.git", "commit1")
+
+				result, warnings, _, err := metadata.MergeWithPrecedence(metadata.Metadata{
+					Dependencies: []metadata.Dependency{dpkgDependency("same"), git},
+				}, metadata.Metadata{
+					Dependencies: []metadata.Dependency{dpkgDependency("same")},
+				}, metadata.FailOnConflict)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(warnings).To(BeEmpty())
+				Expect(result.Dependencies).To(Equal([]metadata.Dependency{dpkgDependency("same"), git}))
+			})
+		})
+	})
 })
 
 func serializeAndDeserializeDependency(currentDpkg metadata.Dependency) (originalDpkg metadata.Dependency) {
@@ -8,6 +8,11 @@ const (
 	GitSourceType               = "git"
 	RPMPackageListSourceType    = "rpm_package_list"
 	ArchiveType                 = "archive"
+	FileSourceType              = "file"
+	HgSourceType                = "hg"
+	SvnSourceType               = "svn"
+	GoModuleSourceType          = "go"
+	ImageSourceType             = "image"
 	PackageType                 = "package"
 	BuildpackMetadataType       = "buildpack_metadata"
 )
@@ -19,13 +24,59 @@ type Metadata struct {
 }
 
 type Provenance struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	URL     string `json:"url"`
+	Name    string           `json:"name"`
+	Version string           `json:"version"`
+	URL     string           `json:"url"`
+	Build   *BuildProvenance `json:"build,omitempty"`
+}
+
+type BuildProvenance struct {
+	StartedOn        string              `json:"started_on"`
+	FinishedOn       string              `json:"finished_on"`
+	InputImage       ProvenanceImage     `json:"input_image"`
+	Flags            map[string][]string `json:"flags"`
+	Providers        []ProviderRun       `json:"providers"`
+	HostOS           string              `json:"host_os"`
+	HostArchitecture string              `json:"host_architecture"`
+}
+
+type ProvenanceImage struct {
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+}
+
+type ProviderRun struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
 }
 
 type Base map[string]string
 
+// The keys of the base which are not read from the image release files, but
+// derived from how the base was detected, from the lifecycle data or from the
+// inputs given when labeling.
+const (
+	BaseEvidenceKey             = "evidence"
+	BaseConfidenceKey           = "confidence"
+	BaseDistrolessKey           = "distroless"
+	BaseImageKey                = "base_image"
+	BaseImageDigestKey          = "base_image_digest"
+	BaseImageSourceKey          = "base_image_source"
+	BaseReleaseDateKey          = "release_date"
+	BaseEndOfStandardSupportKey = "end_of_standard_support"
+	BaseEndOfExtendedSupportKey = "end_of_extended_support"
+)
+
+// DerivedBaseKeys are left out when comparing bases, as labels written by
+// earlier versions do not have them, inspect cannot identify the base image
+// from the candidates given with --base-image, and the lifecycle depends on
+// the data given with --eol-data.
+var DerivedBaseKeys = []string{
+	BaseEvidenceKey, BaseConfidenceKey, BaseDistrolessKey,
+	BaseImageKey, BaseImageDigestKey, BaseImageSourceKey,
+	BaseReleaseDateKey, BaseEndOfStandardSupportKey, BaseEndOfExtendedSupportKey,
+}
+
 type Dependency struct {
 	Type   string `json:"type"`
 	Source Source `json:"source"`
@@ -50,6 +101,36 @@ type BuildpackBOMSourceMetadata struct {
 	Buildpacks      []Buildpack            `json:"buildpacks"`
 	BillOfMaterials []BuildpackBOM         `json:"bom"`
 	Launcher        map[string]interface{} `json:"launcher"`
+	Stack           *BuildpackStack        `json:"stack,omitempty"`
+	RunImage        *BuildpackRunImage     `json:"run_image,omitempty"`
+	Layers          []BuildpackLayer       `json:"layers,omitempty"`
+	Components      []BuildpackComponent   `json:"components,omitempty"`
+}
+
+// BuildpackStack is the stack a buildpacks image was built on.
+type BuildpackStack struct {
+	ID     string   `json:"id"`
+	Mixins []string `json:"mixins,omitempty"`
+}
+
+// BuildpackRunImage is the image a buildpacks image was built on top of, as
+// recorded by the lifecycle.
+type BuildpackRunImage struct {
+	Image     string   `json:"image,omitempty"`
+	Mirrors   []string `json:"mirrors,omitempty"`
+	Reference string   `json:"reference,omitempty"`
+	TopLayer  string   `json:"top_layer,omitempty"`
+}
+
+// BuildpackLayer is a layer of a buildpacks image and the buildpack which
+// contributed it.
+type BuildpackLayer struct {
+	Name      string    `json:"name"`
+	Digest    string    `json:"digest"`
+	Buildpack Buildpack `json:"buildpack"`
+	Build     bool      `json:"build,omitempty"`
+	Launch    bool      `json:"launch,omitempty"`
+	Cache     bool      `json:"cache,omitempty"`
 }
 
 type KpackRepoSourceMetadata struct {
@@ -58,14 +139,82 @@ type KpackRepoSourceMetadata struct {
 }
 
 type GitSourceMetadata struct {
-	URL  string   `json:"url"`
-	Refs []string `json:"refs"`
+	URL             string         `json:"url"`
+	Refs            []string       `json:"refs"`
+	Subdirectory    string         `json:"subdirectory,omitempty"`
+	SubmodulePath   string         `json:"submodule_path,omitempty"`
+	Branch          string         `json:"branch,omitempty"`
+	Describe        *GitDescribe   `json:"describe,omitempty"`
+	CommitTimestamp string         `json:"commit_timestamp,omitempty"`
+	Author          string         `json:"author,omitempty"`
+	Dirty           bool           `json:"dirty,omitempty"`
+	DirtyFiles      *GitDirtyFiles `json:"dirty_files,omitempty"`
+}
+
+// GitDirtyFiles lists the uncommitted changes of a git working tree.
+type GitDirtyFiles struct {
+	Count     int      `json:"count"`
+	Modified  []string `json:"modified"`
+	Untracked []string `json:"untracked"`
+}
+
+// GitDescribe is the nearest tag reachable from a commit, in the manner of
+// `git describe --tags`.
+type GitDescribe struct {
+	Tag         string `json:"tag"`
+	Distance    int    `json:"distance"`
+	Description string `json:"description"`
 }
 
 type ArchiveSourceMetadata struct {
+	URL     string `json:"url"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	License string `json:"license,omitempty"`
+	Sha256  string `json:"sha256,omitempty"`
+	Sha512  string `json:"sha512,omitempty"`
+}
+
+type HgSourceMetadata struct {
 	URL string `json:"url"`
 }
 
+type SvnSourceMetadata struct {
+	URL string `json:"url"`
+}
+
+// GoModuleSourceMetadata is a Go module, with the hash of its content as
+// found in go.sum.
+type GoModuleSourceMetadata struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// ImageSourceMetadata is an image holding the source of a dependency, such
+// as a kpack registry source, with the path of the source within the image.
+type ImageSourceMetadata struct {
+	Image string `json:"image"`
+	Path  string `json:"path,omitempty"`
+}
+
+// FileSourceMetadata describes the files of the image matching a path or
+// glob, with their declared origin and license.
+type FileSourceMetadata struct {
+	Path    string       `json:"path"`
+	Origin  string       `json:"origin,omitempty"`
+	License string       `json:"license,omitempty"`
+	Files   []SourceFile `json:"files"`
+}
+
+// SourceFile is a file of the image with its digest, or a symbolic link with
+// its target.
+type SourceFile struct {
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	Sha256     string `json:"sha256,omitempty"`
+	LinkTarget string `json:"link_target,omitempty"`
+}
+
 type DpkgPackage struct {
 	Package      string        `json:"package"`
 	Version      string        `json:"version"`
@@ -86,11 +235,42 @@ type Buildpack struct {
 	Version string `json:"version"`
 }
 
+// BuildpackComponent is a component listed in an SBOM file written by a
+// buildpack, for one of its layers or for the buildpack as a whole.
+type BuildpackComponent struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version,omitempty"`
+	PURL      string    `json:"purl,omitempty"`
+	Licenses  []string  `json:"licenses,omitempty"`
+	Sha256    string    `json:"sha256,omitempty"`
+	Buildpack Buildpack `json:"buildpack"`
+	Layer     string    `json:"layer,omitempty"`
+	SBOM      string    `json:"sbom"`
+}
+
+// BuildpackBOM is an entry of the bill of materials of a buildpack. The
+// common metadata keys written by the Paketo buildpacks are recognized as
+// typed fields; Metadata holds the keys which are not.
 type BuildpackBOM struct {
 	Name      string               `json:"name"`
 	Version   string               `json:"version"`
+	URI       string               `json:"uri,omitempty"`
+	Sha256    string               `json:"sha256,omitempty"`
+	Licenses  []BuildpackLicense   `json:"licenses,omitempty"`
+	PURL      string               `json:"purl,omitempty"`
+	CPEs      []string             `json:"cpes,omitempty"`
+	Stacks    []string             `json:"stacks,omitempty"`
 	Metadata  BuildpackBOMMetadata `json:"metadata"`
 	Buildpack Buildpack            `json:"buildpack"`
+	// Layers are the digests of the layers contributed by the buildpack
+	Layers []string `json:"layers,omitempty"`
+}
+
+// BuildpackLicense is a license of a bill of materials entry, with the SPDX
+// identifier as its type.
+type BuildpackLicense struct {
+	Type string `json:"type"`
+	URI  string `json:"uri,omitempty"`
 }
 
 type PackageSource struct {
@@ -4,6 +4,7 @@
 package osrelease
 
 import (
+	"regexp"
 	"strings"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
@@ -11,52 +12,128 @@ import (
 	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/image"
+)
+
+// The confidence recorded in the base for the way the operating system was
+// detected.
+const (
+	HighConfidence   = "high"
+	MediumConfidence = "medium"
+	LowConfidence    = "low"
+	NoConfidence     = "none"
+)
+
+const (
+	EvidenceKey   = metadata.BaseEvidenceKey
+	ConfidenceKey = metadata.BaseConfidenceKey
+	// DistrolessKey is "true" in the base of distroless images.
+	DistrolessKey = metadata.BaseDistrolessKey
+)
 
-	"github.com/joho/godotenv"
+const (
+	dpkgStatusFile = "/var/lib/dpkg/status"
+	dpkgStatusDir  = "/var/lib/dpkg/status.d"
 )
 
-func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
-	md.Base = BuildOSMetadata(dli)
-	return md, nil
+var busyboxVersion = regexp.MustCompile(`BusyBox v(\d+(?:\.\d+)+)`)
+
+func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	md.Base = metadata.MergeBase(md.Base, BuildOSMetadata(dli))
+	return md, nil, nil
 }
 
+// BuildOSMetadata detects the operating system of the image from the first
+// release file found, in the order of releaseFiles, and otherwise guesses
+// busybox or scratch from the content of the image. The base records the
+// files used as evidence and how confident the detection is.
 func BuildOSMetadata(dli image.Image) metadata.Base {
-	osRelease, err := dli.GetFileContent("/etc/os-release")
+	base, evidence, confidence := detectOS(dli)
+
+	if isDistroless(dli) {
+		evidence = append(evidence, dpkgStatusDir)
+		if base == nil {
+			base = metadata.Base{
+				"id":               "debian",
+				"name":             "distroless",
+				"pretty_name":      "distroless",
+				"version_codename": "unknown",
+				"version_id":       "unknown",
+			}
+			confidence = MediumConfidence
+		}
+		base = metadata.MergeBase(base, metadata.Base{DistrolessKey: "true"})
+	}
+
+	if base == nil {
+		base, evidence, confidence = guessOS(dli)
+	}
 
-	if err != nil {
-		binContents, binErr := dli.GetDirFileNames("/bin", false)
-		if binErr != nil {
-			return checkScratchBase(dli)
+	base = metadata.MergeBase(base, metadata.Base{ConfidenceKey: confidence})
+	if len(evidence) > 0 {
+		base[EvidenceKey] = strings.Join(evidence, ",")
+	}
+	return base
+}
+
+func detectOS(dli image.Image) (metadata.Base, []string, string) {
+	for _, releaseFile := range releaseFiles {
+		content, err := dli.GetFileContent(releaseFile.path)
+		if err != nil {
+			continue
 		}
 
-		hasAsh := contains(binContents, "ash")
-		if hasAsh {
-			return metadata.BusyboxBase
+		base, ok := releaseFile.parse(content)
+		if !ok {
+			continue
 		}
 
-		return checkScratchBase(dli)
+		return base, []string{releaseFile.path}, releaseFile.confidence
 	}
 
-	envMap, err := godotenv.Unmarshal(osRelease)
-	if err != nil {
-		return metadata.UnknownBase
-	}
+	return nil, nil, ""
+}
 
-	mdBase := metadata.Base{}
-	for k, v := range envMap {
-		mdBase[strings.ToLower(k)] = v
+// isDistroless reports whether the packages of the image are recorded in the
+// way of the distroless images, a file per package in
+// /var/lib/dpkg/status.d rather than a single /var/lib/dpkg/status file.
+func isDistroless(dli image.Image) bool {
+	if _, err := dli.GetFileContent(dpkgStatusFile); err == nil {
+		return false
 	}
 
-	return mdBase
+	files, err := dli.GetDirFileNames(dpkgStatusDir, false)
+	return err == nil && len(files) > 0
 }
 
-func checkScratchBase(dli image.Image) metadata.Base {
+func guessOS(dli image.Image) (metadata.Base, []string, string) {
+	binContents, binErr := dli.GetDirFileNames("/bin", false)
+	if binErr == nil {
+		if contains(binContents, "busybox") {
+			content, err := dli.GetFileContent("/bin/busybox")
+			if err == nil {
+				if match := busyboxVersion.FindStringSubmatch(content); match != nil {
+					return metadata.Base{
+						"name":             "busybox",
+						"pretty_name":      "BusyBox v" + match[1],
+						"version_codename": "unknown",
+						"version_id":       match[1],
+					}, []string{"/bin/busybox"}, MediumConfidence
+				}
+			}
+			return metadata.MergeBase(metadata.BusyboxBase, nil), []string{"/bin/busybox"}, LowConfidence
+		}
+
+		if contains(binContents, "ash") {
+			return metadata.MergeBase(metadata.BusyboxBase, nil), []string{"/bin/ash"}, LowConfidence
+		}
+	}
+
 	rootContents, rootErr := dli.GetDirFileNames("/", true)
-	if rootErr == nil  && len(rootContents) <= 3 {
-		return metadata.ScratchBase
+	if rootErr == nil && len(rootContents) <= 3 {
+		return metadata.MergeBase(metadata.ScratchBase, nil), []string{"/"}, LowConfidence
 	}
 
-	return metadata.UnknownBase
+	return metadata.MergeBase(metadata.UnknownBase, nil), nil, NoConfidence
 }
 
 func contains(a []string, x string) bool {
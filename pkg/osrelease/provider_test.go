@@ -5,17 +5,19 @@ package osrelease_test
 
 import (
 	"fmt"
+
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
 	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
 	. "github.com/vmware-tanzu/dependency-labeler/pkg/osrelease"
 )
 
 type MockImage struct {
-	fileContent string
-	fileContentError error
-	dirFileNames []string
+	files map[string]string
+	dirs  map[string][]string
 }
 
 func (m MockImage) GetConfig() (*v1.ConfigFile, error) {
@@ -26,12 +28,20 @@ func (m MockImage) Cleanup() {
 	panic("implement me")
 }
 
-func (m MockImage) GetFileContent(string) (string, error) {
-	return m.fileContent, m.fileContentError
+func (m MockImage) GetFileContent(path string) (string, error) {
+	content, ok := m.files[path]
+	if !ok {
+		return "", fmt.Errorf("could not find the file")
+	}
+	return content, nil
 }
 
-func (m MockImage) GetDirFileNames(string, bool) ([]string, error) {
-	return m.dirFileNames, nil
+func (m MockImage) GetDirFileNames(path string, _ bool) ([]string, error) {
+	names, ok := m.dirs[path]
+	if !ok {
+		return nil, fmt.Errorf("could not find the directory")
+	}
+	return names, nil
 }
 
 func (m MockImage) GetDirContents(string) ([]string, error) {
@@ -46,62 +56,238 @@ func (m MockImage) ExportWithMetadata(metadata.Metadata, string, string) error {
 	panic("implement me")
 }
 
+func withDetection(base metadata.Base, evidence, confidence string) metadata.Base {
+	base = metadata.MergeBase(base, metadata.Base{ConfidenceKey: confidence})
+	if evidence != "" {
+		base[EvidenceKey] = evidence
+	}
+	return base
+}
+
 var _ = Describe("OsRelease", func() {
+	Describe("Provider", func() {
+		It("adds the os metadata to the base recorded by the other providers", func() {
+			image := MockImage{files: map[string]string{"/etc/os-release": "ID=debian\nVERSION_ID=\"11\"\n"}}
+
+			md, _, err := Provider(image, common.RunParams{}, metadata.Metadata{Base: metadata.Base{"run_image": "registry.example.com/run:1.0"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(md.Base).To(HaveKeyWithValue("run_image", "registry.example.com/run:1.0"))
+			Expect(md.Base).To(HaveKeyWithValue("id", "debian"))
+		})
+	})
+
 	Describe("BuildOSMetadata", func() {
 		Context("when the image has os-release", func() {
 			Context("when os-release is malformed", func() {
-				It("returns properly formatted os metadata", func() {
-					fileContent := "bad\\bad"
+				It("returns unknown os metadata", func() {
+					image := MockImage{files: map[string]string{"/etc/os-release": "bad\\bad"}}
 
-					Expect(BuildOSMetadata(MockImage{fileContent:fileContent, fileContentError:nil})).To(Equal(metadata.UnknownBase))
+					Expect(BuildOSMetadata(image)).To(Equal(withDetection(metadata.UnknownBase, "", NoConfidence)))
 				})
 			})
-			Context("when os-release is properly formatted", func(){
+
+			Context("when os-release is properly formatted", func() {
 				It("returns properly formated os metadata", func() {
 					fileContent := "PRETTY_NAME=\"some-pretty-name\"\nNAME=\"some-name\"\nVERSION_ID=\"some-version-id\"\nVERSION=\"some-version\"\nVERSION_CODENAME=some-version-codename\nID=some-id\nHOME_URL=\"some-url\"\n"
 
-					expectedMetadata := metadata.Base {
-						"id": "some-id",
-						"pretty_name": "some-pretty-name",
-						"version": "some-version",
-						"home_url": "some-url",
-						"version_id": "some-version-id",
+					expectedMetadata := metadata.Base{
+						"id":               "some-id",
+						"pretty_name":      "some-pretty-name",
+						"version":          "some-version",
+						"home_url":         "some-url",
+						"version_id":       "some-version-id",
 						"version_codename": "some-version-codename",
-						"name": "some-name",
+						"name":             "some-name",
+						"evidence":         "/etc/os-release",
+						"confidence":       "high",
 					}
 
-					Expect(BuildOSMetadata(MockImage{fileContent:fileContent, fileContentError:nil})).To(Equal(expectedMetadata))
+					Expect(BuildOSMetadata(MockImage{files: map[string]string{"/etc/os-release": fileContent}})).To(Equal(expectedMetadata))
 				})
 			})
+
+			Context("when os-release is only in /usr/lib", func() {
+				It("returns os metadata from /usr/lib/os-release", func() {
+					image := MockImage{files: map[string]string{
+						"/usr/lib/os-release": "NAME=\"Fedora Linux\"\nVERSION_ID=35\nID=fedora\n",
+					}}
+
+					Expect(BuildOSMetadata(image)).To(Equal(metadata.Base{
+						"name":       "Fedora Linux",
+						"id":         "fedora",
+						"version_id": "35",
+						"evidence":   "/usr/lib/os-release",
+						"confidence": "high",
+					}))
+				})
+			})
+		})
+
+		Context("when the image has a distribution release file", func() {
+			DescribeTable("returns os metadata from the release file",
+				func(path, content string, expected metadata.Base) {
+					image := MockImage{files: map[string]string{path: content}}
+
+					Expect(BuildOSMetadata(image)).To(Equal(withDetection(expected, path, MediumConfidence)))
+				},
+				Entry("lsb-release", "/etc/lsb-release",
+					"DISTRIB_ID=Ubuntu\nDISTRIB_RELEASE=18.04\nDISTRIB_CODENAME=bionic\nDISTRIB_DESCRIPTION=\"Ubuntu 18.04.3 LTS\"\n",
+					metadata.Base{
+						"id":               "ubuntu",
+						"name":             "Ubuntu",
+						"pretty_name":      "Ubuntu 18.04.3 LTS",
+						"version_id":       "18.04",
+						"version_codename": "bionic",
+					}),
+				Entry("alpine-release", "/etc/alpine-release", "3.15.0\n",
+					metadata.Base{
+						"id":               "alpine",
+						"name":             "Alpine Linux",
+						"pretty_name":      "Alpine Linux v3.15.0",
+						"version_id":       "3.15.0",
+						"version_codename": "unknown",
+					}),
+				Entry("photon-release", "/etc/photon-release", "VMware Photon OS 4.0\nPHOTON_BUILD_NUMBER=1526e30\n",
+					metadata.Base{
+						"id":               "photon",
+						"name":             "VMware Photon OS",
+						"pretty_name":      "VMware Photon OS 4.0",
+						"version_id":       "4.0",
+						"version_codename": "unknown",
+					}),
+				Entry("redhat-release", "/etc/redhat-release", "CentOS Linux release 7.9.2009 (Core)\n",
+					metadata.Base{
+						"id":               "centos",
+						"name":             "CentOS Linux",
+						"pretty_name":      "CentOS Linux release 7.9.2009 (Core)",
+						"version_id":       "7.9.2009",
+						"version_codename": "Core",
+					}),
+				Entry("debian_version of a stable version", "/etc/debian_version", "10.11\n",
+					metadata.Base{
+						"id":               "debian",
+						"name":             "Debian GNU/Linux",
+						"pretty_name":      "Debian GNU/Linux 10.11",
+						"version":          "10.11",
+						"version_id":       "10",
+						"version_codename": "unknown",
+					}),
+				Entry("debian_version of the testing version", "/etc/debian_version", "bookworm/sid\n",
+					metadata.Base{
+						"id":               "debian",
+						"name":             "Debian GNU/Linux",
+						"pretty_name":      "Debian GNU/Linux bookworm/sid",
+						"version":          "bookworm/sid",
+						"version_id":       "unknown",
+						"version_codename": "bookworm",
+					}),
+			)
+
+			It("prefers os-release to the other release files", func() {
+				image := MockImage{files: map[string]string{
+					"/etc/os-release":     "NAME=Ubuntu\nID=ubuntu\n",
+					"/etc/debian_version": "buster/sid\n",
+				}}
+
+				Expect(BuildOSMetadata(image)).To(HaveKeyWithValue("evidence", "/etc/os-release"))
+			})
+		})
+
+		Context("when the image records its packages in /var/lib/dpkg/status.d", func() {
+			status := map[string][]string{"/var/lib/dpkg/status.d": {"base-files", "tzdata"}}
+
+			It("returns distroless os metadata", func() {
+				Expect(BuildOSMetadata(MockImage{dirs: status})).To(Equal(metadata.Base{
+					"id":               "debian",
+					"name":             "distroless",
+					"pretty_name":      "distroless",
+					"distroless":       "true",
+					"version_codename": "unknown",
+					"version_id":       "unknown",
+					"evidence":         "/var/lib/dpkg/status.d",
+					"confidence":       "medium",
+				}))
+			})
+
+			It("records it along with the os-release metadata, keeping its variant", func() {
+				image := MockImage{
+					files: map[string]string{"/etc/os-release": "PRETTY_NAME=\"Distroless\"\nID=debian\nVERSION_ID=\"11\"\nVARIANT=\"nonroot\"\n"},
+					dirs:  status,
+				}
+
+				Expect(BuildOSMetadata(image)).To(Equal(metadata.Base{
+					"id":          "debian",
+					"pretty_name": "Distroless",
+					"variant":     "nonroot",
+					"distroless":  "true",
+					"version_id":  "11",
+					"evidence":    "/etc/os-release,/var/lib/dpkg/status.d",
+					"confidence":  "high",
+				}))
+			})
+
+			It("does not consider an image with /var/lib/dpkg/status distroless", func() {
+				image := MockImage{
+					files: map[string]string{"/var/lib/dpkg/status": ""},
+					dirs:  status,
+				}
+
+				Expect(BuildOSMetadata(image)).ToNot(HaveKey("variant"))
+			})
 		})
 
 		Context("when the image does not have os-release", func() {
+			Context("when the image has /bin/busybox", func() {
+				It("returns the busybox version from the binary", func() {
+					image := MockImage{
+						files: map[string]string{"/bin/busybox": "\x7fELF\x00\x00BusyBox v1.34.1 (2021-11-23 00:57:35 UTC)\x00"},
+						dirs:  map[string][]string{"/bin": {"ash", "busybox", "sh"}},
+					}
+
+					Expect(BuildOSMetadata(image)).To(Equal(metadata.Base{
+						"name":             "busybox",
+						"pretty_name":      "BusyBox v1.34.1",
+						"version_codename": "unknown",
+						"version_id":       "1.34.1",
+						"evidence":         "/bin/busybox",
+						"confidence":       "medium",
+					}))
+				})
+
+				It("returns busybox os metadata when the version cannot be found", func() {
+					image := MockImage{
+						files: map[string]string{"/bin/busybox": "\x7fELF"},
+						dirs:  map[string][]string{"/bin": {"busybox"}},
+					}
+
+					Expect(BuildOSMetadata(image)).To(Equal(withDetection(metadata.BusyboxBase, "/bin/busybox", LowConfidence)))
+				})
+			})
+
 			Context("when the image has /bin/ash", func() {
 				It("returns busybox os metadata", func() {
 					dirFileNames := []string{"sh", "bash", "rash", "dash", "stash", "ash"}
-					fileContentError := fmt.Errorf("could not find the file")
 
-					Expect(BuildOSMetadata(MockImage{dirFileNames:dirFileNames, fileContentError:fileContentError})).To(Equal(metadata.BusyboxBase))
+					Expect(BuildOSMetadata(MockImage{dirs: map[string][]string{"/bin": dirFileNames}})).To(Equal(withDetection(metadata.BusyboxBase, "/bin/ash", LowConfidence)))
 				})
 			})
 
 			Context("when the image does not have /bin/ash", func() {
 				Context("when there are 3 or less file/folders in the root directory", func() {
 					It("returns scratch base os metadata", func() {
-						fileContentError := fmt.Errorf("could not find the file")
 						rootContentNames := []string{"bin", "dev", "sys"}
 
-						Expect(BuildOSMetadata(MockImage{dirFileNames:rootContentNames, fileContentError:fileContentError})).To(Equal(metadata.ScratchBase))
+						Expect(BuildOSMetadata(MockImage{dirs: map[string][]string{"/": rootContentNames}})).To(Equal(withDetection(metadata.ScratchBase, "/", LowConfidence)))
 					})
 				})
 
 				It("returns unknown base os metadata", func() {
 					dirFileNames := []string{"sh", "bash", "rash", "dash", "stash"}
-					fileContentError := fmt.Errorf("could not find the file")
 
-					Expect(BuildOSMetadata(MockImage{dirFileNames:dirFileNames, fileContentError:fileContentError})).To(Equal(metadata.UnknownBase))
+					Expect(BuildOSMetadata(MockImage{dirs: map[string][]string{"/bin": dirFileNames, "/": dirFileNames}})).To(Equal(withDetection(metadata.UnknownBase, "", NoConfidence)))
 				})
 			})
 		})
 	})
-})
\ No newline at end of file
+})
@@ -0,0 +1,165 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package osrelease
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+// releaseFiles are the files describing the operating system of an image, by
+// preference. The os-release files are the most complete, the distribution
+// specific files give little more than a name and a version.
+var releaseFiles = []struct {
+	path       string
+	confidence string
+	parse      func(string) (metadata.Base, bool)
+}{
+	{"/etc/os-release", HighConfidence, parseOSRelease},
+	{"/usr/lib/os-release", HighConfidence, parseOSRelease},
+	{"/etc/lsb-release", MediumConfidence, parseLSBRelease},
+	{"/etc/alpine-release", MediumConfidence, parseAlpineRelease},
+	{"/etc/photon-release", MediumConfidence, parsePhotonRelease},
+	{"/etc/redhat-release", MediumConfidence, parseRedhatRelease},
+	{"/etc/debian_version", MediumConfidence, parseDebianVersion},
+}
+
+var (
+	photonRelease  = regexp.MustCompile(`^(.+?)\s+(\d+(?:\.\d+)*)$`)
+	redhatRelease  = regexp.MustCompile(`^(.+?) release (\S+)(?: \((.+)\))?`)
+	redhatIDPrefix = []struct{ prefix, id string }{
+		{"Red Hat", "rhel"},
+		{"CentOS", "centos"},
+		{"Fedora", "fedora"},
+		{"Rocky", "rocky"},
+		{"AlmaLinux", "almalinux"},
+	}
+)
+
+// parseOSRelease records all the keys of an os-release file, lower-cased.
+func parseOSRelease(content string) (metadata.Base, bool) {
+	envMap, err := godotenv.Unmarshal(content)
+	if err != nil || len(envMap) == 0 {
+		return nil, false
+	}
+
+	base := metadata.Base{}
+	for k, v := range envMap {
+		base[strings.ToLower(k)] = v
+	}
+	return base, true
+}
+
+func parseLSBRelease(content string) (metadata.Base, bool) {
+	envMap, err := godotenv.Unmarshal(content)
+	if err != nil || envMap["DISTRIB_ID"] == "" {
+		return nil, false
+	}
+
+	return withUnknownVersion(metadata.Base{
+		"id":               strings.ToLower(envMap["DISTRIB_ID"]),
+		"name":             envMap["DISTRIB_ID"],
+		"pretty_name":      envMap["DISTRIB_DESCRIPTION"],
+		"version_id":       envMap["DISTRIB_RELEASE"],
+		"version_codename": envMap["DISTRIB_CODENAME"],
+	}), true
+}
+
+// parseAlpineRelease reads the version written alone in /etc/alpine-release.
+func parseAlpineRelease(content string) (metadata.Base, bool) {
+	version := strings.TrimSpace(content)
+	if version == "" {
+		return nil, false
+	}
+
+	return withUnknownVersion(metadata.Base{
+		"id":          "alpine",
+		"name":        "Alpine Linux",
+		"pretty_name": "Alpine Linux v" + version,
+		"version_id":  version,
+	}), true
+}
+
+// parsePhotonRelease reads the first line of /etc/photon-release, e.g.
+// "VMware Photon OS 4.0", which is followed by the build number.
+func parsePhotonRelease(content string) (metadata.Base, bool) {
+	firstLine := strings.TrimSpace(strings.SplitN(content, "\n", 2)[0])
+	match := photonRelease.FindStringSubmatch(firstLine)
+	if match == nil {
+		return nil, false
+	}
+
+	return withUnknownVersion(metadata.Base{
+		"id":          "photon",
+		"name":        match[1],
+		"pretty_name": firstLine,
+		"version_id":  match[2],
+	}), true
+}
+
+// parseRedhatRelease reads /etc/redhat-release, e.g. "CentOS Linux release
+// 7.9.2009 (Core)", which is also written by the distributions derived from
+// Red Hat Enterprise Linux.
+func parseRedhatRelease(content string) (metadata.Base, bool) {
+	line := strings.TrimSpace(content)
+	match := redhatRelease.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+
+	name := match[1]
+	id := strings.ToLower(strings.Fields(name)[0])
+	for _, p := range redhatIDPrefix {
+		if strings.HasPrefix(name, p.prefix) {
+			id = p.id
+			break
+		}
+	}
+
+	return withUnknownVersion(metadata.Base{
+		"id":               id,
+		"name":             name,
+		"pretty_name":      line,
+		"version_id":       match[2],
+		"version_codename": match[3],
+	}), true
+}
+
+// parseDebianVersion reads /etc/debian_version, which holds either the point
+// release of a stable version, e.g. "10.11", or the codename of the testing
+// version, e.g. "bookworm/sid".
+func parseDebianVersion(content string) (metadata.Base, bool) {
+	version := strings.TrimSpace(content)
+	if version == "" {
+		return nil, false
+	}
+
+	base := metadata.Base{
+		"id":          "debian",
+		"name":        "Debian GNU/Linux",
+		"pretty_name": "Debian GNU/Linux " + version,
+		"version":     version,
+	}
+	if i := strings.Index(version, "/"); i >= 0 {
+		base["version_codename"] = version[:i]
+	} else {
+		base["version_id"] = strings.SplitN(version, ".", 2)[0]
+	}
+	return withUnknownVersion(base), true
+}
+
+// withUnknownVersion sets the version keys which the release file does not
+// give to unknown, as for the images without release file.
+func withUnknownVersion(base metadata.Base) metadata.Base {
+	for _, key := range []string{"version_codename", "version_id"} {
+		if base[key] == "" {
+			base[key] = "unknown"
+		}
+	}
+	return base
+}
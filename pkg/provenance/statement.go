@@ -0,0 +1,128 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package provenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+const (
+	StatementType = "https://in-toto.io/Statement/v0.1"
+	PredicateType = "https://slsa.dev/provenance/v0.2"
+	BuildType     = "https://github.com/vmware-tanzu/dependency-labeler/label@v1"
+)
+
+// Statement is an in-toto statement carrying a SLSA provenance predicate.
+type Statement struct {
+	Type          string    `json:"_type"`
+	PredicateType string    `json:"predicateType"`
+	Subject       []Subject `json:"subject"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type Predicate struct {
+	Builder     Builder       `json:"builder"`
+	BuildType   string        `json:"buildType"`
+	Invocation  Invocation    `json:"invocation"`
+	Metadata    BuildMetadata `json:"metadata"`
+	BuildConfig BuildConfig   `json:"buildConfig"`
+	Materials   []Material    `json:"materials"`
+}
+
+type BuildConfig struct {
+	Providers []metadata.ProviderRun `json:"providers"`
+}
+
+type Builder struct {
+	ID string `json:"id"`
+}
+
+type Invocation struct {
+	Parameters  map[string][]string `json:"parameters"`
+	Environment map[string]string   `json:"environment"`
+}
+
+type BuildMetadata struct {
+	BuildStartedOn  string `json:"buildStartedOn"`
+	BuildFinishedOn string `json:"buildFinishedOn"`
+}
+
+type Material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// NewStatement describes the labeling of the input image of the build into
+// the output image with the given name and digest.
+func NewStatement(tool metadata.Provenance, outputName, outputDigest string) Statement {
+	build := metadata.BuildProvenance{}
+	if tool.Build != nil {
+		build = *tool.Build
+	}
+
+	return Statement{
+		Type:          StatementType,
+		PredicateType: PredicateType,
+		Subject: []Subject{{
+			Name:   outputName,
+			Digest: digestMap(outputDigest),
+		}},
+		Predicate: Predicate{
+			Builder:   Builder{ID: tool.URL + "@" + tool.Version},
+			BuildType: BuildType,
+			Invocation: Invocation{
+				Parameters: build.Flags,
+				Environment: map[string]string{
+					"os":           build.HostOS,
+					"architecture": build.HostArchitecture,
+				},
+			},
+			Metadata: BuildMetadata{
+				BuildStartedOn:  build.StartedOn,
+				BuildFinishedOn: build.FinishedOn,
+			},
+			BuildConfig: BuildConfig{
+				Providers: build.Providers,
+			},
+			Materials: []Material{{
+				URI:    build.InputImage.Reference,
+				Digest: digestMap(build.InputImage.Digest),
+			}},
+		},
+	}
+}
+
+func WriteStatementFile(statement Statement, statementFilePath string) error {
+	statementFile, err := os.Create(statementFilePath)
+	if err != nil {
+		return fmt.Errorf("could not create file %s: %w", statementFilePath, err)
+	}
+	defer statementFile.Close()
+
+	encoder := json.NewEncoder(statementFile)
+	err = encoder.Encode(statement)
+	if err != nil {
+		return fmt.Errorf("could not write provenance statement file: %w", err)
+	}
+	return nil
+}
+
+// digestMap turns an "algorithm:hex" digest into the in-toto digest set form.
+func digestMap(digest string) map[string]string {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return map[string]string{}
+	}
+	return map[string]string{parts[0]: parts[1]}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package provenance_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/provenance"
+)
+
+var _ = Describe("Statement", func() {
+	Describe("NewStatement", func() {
+		It("describes the labeled image as the subject and the input image as a material", func() {
+			statement := NewStatement(metadata.Provenance{
+				Name:    "deplab",
+				Version: "1.2.3",
+				URL:     "https://github.com/vmware-tanzu/dependency-labeler",
+				Build: &metadata.BuildProvenance{
+					StartedOn:        "2020-01-02T03:04:05Z",
+					FinishedOn:       "2020-01-02T03:05:05Z",
+					InputImage:       metadata.ProvenanceImage{Reference: "example.com/image:tag", Digest: "sha256:abc"},
+					Flags:            map[string][]string{"image": {"example.com/image:tag"}},
+					Providers:        []metadata.ProviderRun{{Name: "dpkg", DurationMs: 12}},
+					HostOS:           "linux",
+					HostArchitecture: "amd64",
+				},
+			}, "example.com/image:labeled", "sha256:def")
+
+			Expect(statement.Type).To(Equal(StatementType))
+			Expect(statement.PredicateType).To(Equal(PredicateType))
+			Expect(statement.Subject).To(Equal([]Subject{{
+				Name:   "example.com/image:labeled",
+				Digest: map[string]string{"sha256": "def"},
+			}}))
+			Expect(statement.Predicate.Builder.ID).To(Equal("https://github.com/vmware-tanzu/dependency-labeler@1.2.3"))
+			Expect(statement.Predicate.Invocation.Parameters).To(Equal(map[string][]string{"image": {"example.com/image:tag"}}))
+			Expect(statement.Predicate.Invocation.Environment).To(Equal(map[string]string{"os": "linux", "architecture": "amd64"}))
+			Expect(statement.Predicate.Metadata).To(Equal(BuildMetadata{
+				BuildStartedOn:  "2020-01-02T03:04:05Z",
+				BuildFinishedOn: "2020-01-02T03:05:05Z",
+			}))
+			Expect(statement.Predicate.BuildConfig.Providers).To(Equal([]metadata.ProviderRun{{Name: "dpkg", DurationMs: 12}}))
+			Expect(statement.Predicate.Materials).To(Equal([]Material{{
+				URI:    "example.com/image:tag",
+				Digest: map[string]string{"sha256": "abc"},
+			}}))
+		})
+	})
+})
@@ -0,0 +1,184 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package referrer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	EmptyConfigMediaType types.MediaType = "application/vnd.oci.empty.v1+json"
+	CreatedAnnotation                    = "org.opencontainers.image.created"
+)
+
+var emptyConfig = []byte("{}")
+
+// Manifest is an OCI image manifest carrying an artifact. go-containerregistry
+// does not know about the artifactType and subject fields, so the manifest is
+// encoded here.
+type Manifest struct {
+	SchemaVersion int64             `json:"schemaVersion"`
+	MediaType     types.MediaType   `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType"`
+	Config        v1.Descriptor     `json:"config"`
+	Layers        []v1.Descriptor   `json:"layers"`
+	Subject       *v1.Descriptor    `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Descriptor is an OCI descriptor with the artifactType field, as found in
+// referrers indexes.
+type Descriptor struct {
+	MediaType    types.MediaType   `json:"mediaType"`
+	Size         int64             `json:"size"`
+	Digest       v1.Hash           `json:"digest"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Index is an OCI image index listing the referrers of a manifest.
+type Index struct {
+	SchemaVersion int64           `json:"schemaVersion"`
+	MediaType     types.MediaType `json:"mediaType"`
+	Manifests     []Descriptor    `json:"manifests"`
+}
+
+// NewArtifact returns an image holding content as its only layer, with a
+// manifest whose subject is the given descriptor.
+func NewArtifact(content []byte, mediaType string, subject v1.Descriptor, annotations map[string]string) (v1.Image, error) {
+	layerDigest, layerSize, err := v1.SHA256(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("could not compute digest of the artifact content: %w", err)
+	}
+
+	configDigest, configSize, err := v1.SHA256(bytes.NewReader(emptyConfig))
+	if err != nil {
+		return nil, fmt.Errorf("could not compute digest of the artifact config: %w", err)
+	}
+
+	manifest, err := json.Marshal(Manifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		ArtifactType:  mediaType,
+		Config: v1.Descriptor{
+			MediaType: EmptyConfigMediaType,
+			Size:      configSize,
+			Digest:    configDigest,
+		},
+		Layers: []v1.Descriptor{{
+			MediaType: types.MediaType(mediaType),
+			Size:      layerSize,
+			Digest:    layerDigest,
+		}},
+		Subject:     &subject,
+		Annotations: annotations,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not encode artifact manifest: %w", err)
+	}
+
+	return partial.CompressedToImage(&artifact{
+		manifest: manifest,
+		layer: artifactLayer{
+			content:   content,
+			digest:    layerDigest,
+			mediaType: types.MediaType(mediaType),
+		},
+	})
+}
+
+type artifact struct {
+	manifest []byte
+	layer    artifactLayer
+}
+
+func (a *artifact) RawConfigFile() ([]byte, error) {
+	return emptyConfig, nil
+}
+
+func (a *artifact) MediaType() (types.MediaType, error) {
+	return types.OCIManifestSchema1, nil
+}
+
+func (a *artifact) RawManifest() ([]byte, error) {
+	return a.manifest, nil
+}
+
+func (a *artifact) LayerByDigest(h v1.Hash) (partial.CompressedLayer, error) {
+	if h != a.layer.digest {
+		return nil, fmt.Errorf("artifact has no layer %s", h)
+	}
+	return a.layer, nil
+}
+
+type artifactLayer struct {
+	content   []byte
+	digest    v1.Hash
+	mediaType types.MediaType
+}
+
+func (l artifactLayer) Digest() (v1.Hash, error) {
+	return l.digest, nil
+}
+
+func (l artifactLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.content)), nil
+}
+
+func (l artifactLayer) Size() (int64, error) {
+	return int64(len(l.content)), nil
+}
+
+func (l artifactLayer) MediaType() (types.MediaType, error) {
+	return l.mediaType, nil
+}
+
+// DescriptorOf returns the descriptor of an artifact, as listed in a referrers
+// index.
+func DescriptorOf(img v1.Image, artifactType string, annotations map[string]string) (Descriptor, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return Descriptor{}, fmt.Errorf("could not compute artifact digest: %w", err)
+	}
+
+	size, err := img.Size()
+	if err != nil {
+		return Descriptor{}, fmt.Errorf("could not compute artifact size: %w", err)
+	}
+
+	return Descriptor{
+		MediaType:    types.OCIManifestSchema1,
+		Size:         size,
+		Digest:       digest,
+		ArtifactType: artifactType,
+		Annotations:  annotations,
+	}, nil
+}
+
+// content returns the content of the only layer of an artifact.
+func content(img v1.Image) ([]byte, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("could not read artifact layers: %w", err)
+	}
+	if len(layers) != 1 {
+		return nil, fmt.Errorf("expected artifact to have 1 layer, found %d", len(layers))
+	}
+
+	rc, err := layers[0].Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("could not read artifact content: %w", err)
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
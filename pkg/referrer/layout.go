@@ -0,0 +1,109 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package referrer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func attachToLayout(layoutPath string, artifact v1.Image, descriptor Descriptor) error {
+	path, err := openOrCreateLayout(layoutPath)
+	if err != nil {
+		return err
+	}
+
+	return path.AppendImage(artifact, layout.WithAnnotations(descriptor.Annotations))
+}
+
+func openOrCreateLayout(layoutPath string) (layout.Path, error) {
+	if _, err := os.Stat(layoutPath); os.IsNotExist(err) {
+		return layout.Write(layoutPath, empty.Index)
+	}
+
+	return layout.FromPath(layoutPath)
+}
+
+func fetchFromLayout(layoutPath string, digest v1.Hash) ([]byte, error) {
+	path, err := layout.FromPath(layoutPath)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, err := path.Image(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return content(artifact)
+}
+
+// discoverInLayout reads every manifest of the layout looking for the ones
+// whose subject is the given digest, as OCI layouts have no referrers index.
+func discoverInLayout(layoutPath string, subject v1.Hash, artifactType string) ([]Referrer, error) {
+	path, err := layout.FromPath(layoutPath)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := path.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var referrers []Referrer
+	for _, desc := range indexManifest.Manifests {
+		if desc.MediaType != types.OCIManifestSchema1 {
+			continue
+		}
+
+		rawManifest, err := path.Bytes(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("could not read manifest %s: %w", desc.Digest, err)
+		}
+
+		var manifest Manifest
+		err = json.Unmarshal(rawManifest, &manifest)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode manifest %s: %w", desc.Digest, err)
+		}
+
+		if manifest.Subject == nil || manifest.Subject.Digest != subject || len(manifest.Layers) != 1 {
+			continue
+		}
+
+		if artifactType != "" && manifest.ArtifactType != artifactType {
+			continue
+		}
+
+		content, err := path.Bytes(manifest.Layers[0].Digest)
+		if err != nil {
+			return nil, fmt.Errorf("could not read artifact content %s: %w", manifest.Layers[0].Digest, err)
+		}
+
+		referrers = append(referrers, Referrer{
+			Descriptor: Descriptor{
+				MediaType:    desc.MediaType,
+				Size:         desc.Size,
+				Digest:       desc.Digest,
+				ArtifactType: manifest.ArtifactType,
+				Annotations:  manifest.Annotations,
+			},
+			Content: content,
+		})
+	}
+
+	return referrers, nil
+}
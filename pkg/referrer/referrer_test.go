@@ -0,0 +1,219 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package referrer_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/referrer"
+)
+
+var _ = Describe("Referrer", func() {
+	subject := v1.Descriptor{
+		MediaType: types.DockerManifestSchema2,
+		Size:      1234,
+		Digest:    v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("a", 64)},
+	}
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	Describe("NewArtifact", func() {
+		It("creates an artifact manifest with the subject and the content as its only layer", func() {
+			artifact, err := NewArtifact([]byte(`{"sbom":true}`), "application/spdx+json", subject, map[string]string{"key": "value"})
+			Expect(err).ToNot(HaveOccurred())
+
+			rawManifest, err := artifact.RawManifest()
+			Expect(err).ToNot(HaveOccurred())
+
+			var manifest Manifest
+			Expect(json.Unmarshal(rawManifest, &manifest)).To(Succeed())
+
+			Expect(manifest.MediaType).To(Equal(types.OCIManifestSchema1))
+			Expect(manifest.ArtifactType).To(Equal("application/spdx+json"))
+			Expect(manifest.Config.MediaType).To(Equal(EmptyConfigMediaType))
+			Expect(manifest.Config.Size).To(Equal(int64(2)))
+			Expect(manifest.Subject).To(Equal(&subject))
+			Expect(manifest.Layers).To(HaveLen(1))
+			Expect(manifest.Layers[0].MediaType).To(Equal(types.MediaType("application/spdx+json")))
+			Expect(manifest.Annotations).To(Equal(map[string]string{"key": "value"}))
+		})
+	})
+
+	Describe("ParseTarget", func() {
+		It("parses an oci layout target", func() {
+			target, err := ParseTarget("oci:/tmp/layout")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.LayoutPath).To(Equal("/tmp/layout"))
+		})
+
+		It("parses a repository target", func() {
+			target, err := ParseTarget("example.com/org/repo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.LayoutPath).To(BeEmpty())
+			Expect(target.Repository.String()).To(Equal("example.com/org/repo"))
+		})
+
+		It("returns an error for an invalid target", func() {
+			_, err := ParseTarget("oci:")
+			Expect(err).To(HaveOccurred())
+
+			_, err = ParseTarget("Not A Repository!")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with an oci layout", func() {
+		var layoutDir string
+
+		BeforeEach(func() {
+			var err error
+			layoutDir, err = ioutil.TempDir("", "deplab-referrer")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(layoutDir)
+		})
+
+		It("attaches artifacts and discovers them by subject and type", func() {
+			target, err := ParseTarget("oci:" + filepath.Join(layoutDir, "layout"))
+			Expect(err).ToNot(HaveOccurred())
+
+			spdx, err := target.Attach([]byte(`{"spdx":true}`), "application/spdx+json", subject, created)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = target.Attach([]byte(`{"deplab":true}`), "application/vnd.deplab.metadata.v1+json", subject, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			otherSubject := subject
+			otherSubject.Digest = v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("b", 64)}
+			_, err = target.Attach([]byte(`{"other":true}`), "application/spdx+json", otherSubject, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			referrers, err := target.Discover(subject.Digest, "application/spdx+json")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(referrers).To(HaveLen(1))
+			Expect(referrers[0].Descriptor.Digest).To(Equal(spdx.Digest))
+			Expect(referrers[0].Descriptor.Annotations).To(HaveKeyWithValue(CreatedAnnotation, "2020-01-02T03:04:05Z"))
+			Expect(referrers[0].Content).To(MatchJSON(`{"spdx":true}`))
+
+			allReferrers, err := target.Discover(subject.Digest, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allReferrers).To(HaveLen(2))
+		})
+
+		It("fetches the content of an artifact by digest", func() {
+			target, err := ParseTarget("oci:" + filepath.Join(layoutDir, "layout"))
+			Expect(err).ToNot(HaveOccurred())
+
+			descriptor, err := target.Attach([]byte(`{"deplab":true}`), "application/vnd.deplab.metadata.v1+json", subject, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(target.Fetch(descriptor.Digest)).To(MatchJSON(`{"deplab":true}`))
+
+			_, err = target.Fetch(subject.Digest)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with a registry", func() {
+		var (
+			server           *httptest.Server
+			referrersIndex   *Index
+			referrersQueried bool
+		)
+
+		BeforeEach(func() {
+			referrersIndex = nil
+			referrersQueried = false
+			registryHandler := registry.New(registry.Logger(log.New(ioutil.Discard, "", 0)))
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.Contains(r.URL.Path, "/referrers/") && referrersIndex != nil {
+					referrersQueried = true
+					w.Header().Set("Content-Type", string(types.OCIImageIndex))
+					Expect(json.NewEncoder(w).Encode(referrersIndex)).To(Succeed())
+					return
+				}
+				registryHandler.ServeHTTP(w, r)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		repository := func() name.Repository {
+			u := strings.TrimPrefix(server.URL, "http://")
+			repository, err := name.NewRepository(u + "/org/image")
+			Expect(err).ToNot(HaveOccurred())
+			return repository
+		}
+
+		It("falls back to the tag schema when the registry has no referrers api", func() {
+			target := Target{Repository: repository()}
+
+			descriptor, err := target.Attach([]byte(`{"deplab":true}`), "application/vnd.deplab.metadata.v1+json", subject, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("tagging an index of the referrers after the subject")
+			desc, err := remote.Get(repository().Tag(TagSchemaTag(subject.Digest)))
+			Expect(err).ToNot(HaveOccurred())
+			var index Index
+			Expect(json.Unmarshal(desc.Manifest, &index)).To(Succeed())
+			Expect(index.Manifests).To(HaveLen(1))
+			Expect(index.Manifests[0].Digest).To(Equal(descriptor.Digest))
+			Expect(index.Manifests[0].ArtifactType).To(Equal("application/vnd.deplab.metadata.v1+json"))
+
+			By("discovering the artifact through the tag")
+			referrers, err := target.Discover(subject.Digest, "application/vnd.deplab.metadata.v1+json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(referrers).To(HaveLen(1))
+			Expect(referrers[0].Content).To(MatchJSON(`{"deplab":true}`))
+		})
+
+		It("uses the referrers api when the registry supports it", func() {
+			target := Target{Repository: repository()}
+			referrersIndex = &Index{SchemaVersion: 2, MediaType: types.OCIImageIndex, Manifests: []Descriptor{}}
+
+			descriptor, err := target.Attach([]byte(`{"deplab":true}`), "application/vnd.deplab.metadata.v1+json", subject, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = remote.Get(repository().Tag(TagSchemaTag(subject.Digest)))
+			Expect(err).To(HaveOccurred())
+
+			referrersIndex.Manifests = append(referrersIndex.Manifests, descriptor)
+			referrers, err := target.Discover(subject.Digest, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(referrersQueried).To(BeTrue())
+			Expect(referrers).To(HaveLen(1))
+			Expect(referrers[0].Content).To(MatchJSON(`{"deplab":true}`))
+		})
+
+		It("fetches the content of an artifact by digest", func() {
+			target := Target{Repository: repository()}
+
+			descriptor, err := target.Attach([]byte(`{"deplab":true}`), "application/vnd.deplab.metadata.v1+json", subject, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(target.Fetch(descriptor.Digest)).To(MatchJSON(`{"deplab":true}`))
+		})
+	})
+})
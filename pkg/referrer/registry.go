@@ -0,0 +1,188 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package referrer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func attachToRegistry(repository name.Repository, artifact v1.Image, descriptor Descriptor, subject v1.Hash) error {
+	err := remote.Write(repository.Digest(descriptor.Digest.String()), artifact, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return fmt.Errorf("could not push artifact: %w", err)
+	}
+
+	_, supported, err := fetchReferrersIndex(repository, subject)
+	if err != nil {
+		return err
+	}
+	if supported {
+		// the registry indexes the artifact by its subject itself
+		return nil
+	}
+
+	return addToTagSchemaIndex(repository, subject, descriptor)
+}
+
+func discoverInRegistry(repository name.Repository, subject v1.Hash, artifactType string) ([]Referrer, error) {
+	index, supported, err := fetchReferrersIndex(repository, subject)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		index, err = fetchTagSchemaIndex(repository, subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var referrers []Referrer
+	for _, descriptor := range index.Manifests {
+		if artifactType != "" && descriptor.ArtifactType != artifactType {
+			continue
+		}
+
+		artifact, err := remote.Image(repository.Digest(descriptor.Digest.String()), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch artifact %s: %w", descriptor.Digest, err)
+		}
+
+		artifactContent, err := content(artifact)
+		if err != nil {
+			return nil, err
+		}
+
+		referrers = append(referrers, Referrer{Descriptor: descriptor, Content: artifactContent})
+	}
+
+	return referrers, nil
+}
+
+func fetchFromRegistry(repository name.Repository, digest v1.Hash) ([]byte, error) {
+	artifact, err := remote.Image(repository.Digest(digest.String()), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, err
+	}
+
+	return content(artifact)
+}
+
+// fetchReferrersIndex queries the referrers API of the registry. It reports
+// the API as unsupported when the registry does not know the endpoint.
+func fetchReferrersIndex(repository name.Repository, subject v1.Hash) (Index, bool, error) {
+	auth, err := authn.DefaultKeychain.Resolve(repository)
+	if err != nil {
+		return Index{}, false, fmt.Errorf("could not resolve credentials for %s: %w", repository, err)
+	}
+
+	rt, err := transport.NewWithContext(context.Background(), repository.Registry, auth, http.DefaultTransport, []string{repository.Scope(transport.PullScope)})
+	if err != nil {
+		return Index{}, false, fmt.Errorf("could not connect to %s: %w", repository.Registry, err)
+	}
+
+	u := url.URL{
+		Scheme: repository.Registry.Scheme(),
+		Host:   repository.RegistryStr(),
+		Path:   fmt.Sprintf("/v2/%s/referrers/%s", repository.RepositoryStr(), subject),
+	}
+
+	resp, err := (&http.Client{Transport: rt}).Get(u.String())
+	if err != nil {
+		return Index{}, false, fmt.Errorf("could not query referrers of %s: %w", subject, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Index{}, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return Index{}, false, fmt.Errorf("got status code %d when querying referrers of %s (expected 200)", resp.StatusCode, subject)
+	}
+
+	var index Index
+	err = json.NewDecoder(resp.Body).Decode(&index)
+	if err != nil {
+		return Index{}, false, fmt.Errorf("could not decode referrers index: %w", err)
+	}
+
+	return index, true, nil
+}
+
+// TagSchemaTag is the tag holding the referrers index of a subject in
+// registries without the referrers API, e.g. sha256-<hex>.
+func TagSchemaTag(subject v1.Hash) string {
+	return strings.Replace(subject.String(), ":", "-", 1)
+}
+
+func fetchTagSchemaIndex(repository name.Repository, subject v1.Hash) (Index, error) {
+	desc, err := remote.Get(repository.Tag(TagSchemaTag(subject)), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+			return Index{SchemaVersion: 2, MediaType: types.OCIImageIndex, Manifests: []Descriptor{}}, nil
+		}
+		return Index{}, fmt.Errorf("could not fetch referrers tag: %w", err)
+	}
+
+	var index Index
+	err = json.Unmarshal(desc.Manifest, &index)
+	if err != nil {
+		return Index{}, fmt.Errorf("could not decode referrers tag index: %w", err)
+	}
+
+	return index, nil
+}
+
+func addToTagSchemaIndex(repository name.Repository, subject v1.Hash, descriptor Descriptor) error {
+	index, err := fetchTagSchemaIndex(repository, subject)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range index.Manifests {
+		if existing.Digest == descriptor.Digest {
+			return nil
+		}
+	}
+	index.Manifests = append(index.Manifests, descriptor)
+
+	rawIndex, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("could not encode referrers tag index: %w", err)
+	}
+
+	err = remote.Put(repository.Tag(TagSchemaTag(subject)), rawManifest{raw: rawIndex, mediaType: types.OCIImageIndex}, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return fmt.Errorf("could not push referrers tag index: %w", err)
+	}
+
+	return nil
+}
+
+type rawManifest struct {
+	raw       []byte
+	mediaType types.MediaType
+}
+
+func (m rawManifest) RawManifest() ([]byte, error) {
+	return m.raw, nil
+}
+
+func (m rawManifest) MediaType() (types.MediaType, error) {
+	return m.mediaType, nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package referrer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// LayoutPrefix marks a target as a path to an OCI layout rather than a
+// registry repository.
+const LayoutPrefix = "oci:"
+
+// Target is where referrer artifacts are attached to and discovered from:
+// either an OCI layout on disk or a repository in a registry.
+type Target struct {
+	LayoutPath string
+	Repository name.Repository
+}
+
+// Referrer is an artifact found for a subject, with its content.
+type Referrer struct {
+	Descriptor Descriptor
+	Content    []byte
+}
+
+func ParseTarget(target string) (Target, error) {
+	if strings.HasPrefix(target, LayoutPrefix) {
+		layoutPath := strings.TrimPrefix(target, LayoutPrefix)
+		if layoutPath == "" {
+			return Target{}, fmt.Errorf("referrer target %s is missing the layout path", target)
+		}
+		return Target{LayoutPath: layoutPath}, nil
+	}
+
+	repository, err := name.NewRepository(target)
+	if err != nil {
+		return Target{}, fmt.Errorf("referrer target %s is neither an oci layout nor a repository: %w", target, err)
+	}
+	return Target{Repository: repository}, nil
+}
+
+func (t Target) String() string {
+	if t.LayoutPath != "" {
+		return LayoutPrefix + t.LayoutPath
+	}
+	return t.Repository.String()
+}
+
+// Attach stores content as an artifact referring to the subject and returns
+// the descriptor of the artifact.
+func (t Target) Attach(content []byte, mediaType string, subject v1.Descriptor, created time.Time) (Descriptor, error) {
+	annotations := map[string]string{
+		CreatedAnnotation: created.UTC().Format(time.RFC3339),
+	}
+
+	artifact, err := NewArtifact(content, mediaType, subject, annotations)
+	if err != nil {
+		return Descriptor{}, err
+	}
+
+	descriptor, err := DescriptorOf(artifact, mediaType, annotations)
+	if err != nil {
+		return Descriptor{}, err
+	}
+
+	if t.LayoutPath != "" {
+		err = attachToLayout(t.LayoutPath, artifact, descriptor)
+	} else {
+		err = attachToRegistry(t.Repository, artifact, descriptor, subject.Digest)
+	}
+	if err != nil {
+		return Descriptor{}, fmt.Errorf("could not attach artifact to %s: %w", t, err)
+	}
+
+	return descriptor, nil
+}
+
+// Discover returns the artifacts of the given type referring to the subject.
+// All artifacts are returned when artifactType is empty.
+func (t Target) Discover(subject v1.Hash, artifactType string) ([]Referrer, error) {
+	var (
+		referrers []Referrer
+		err       error
+	)
+
+	if t.LayoutPath != "" {
+		referrers, err = discoverInLayout(t.LayoutPath, subject, artifactType)
+	} else {
+		referrers, err = discoverInRegistry(t.Repository, subject, artifactType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not discover referrers of %s in %s: %w", subject, t, err)
+	}
+
+	return referrers, nil
+}
+
+// Fetch returns the content of the artifact with the given digest.
+func (t Target) Fetch(digest v1.Hash) ([]byte, error) {
+	var (
+		artifactContent []byte
+		err             error
+	)
+
+	if t.LayoutPath != "" {
+		artifactContent, err = fetchFromLayout(t.LayoutPath, digest)
+	} else {
+		artifactContent, err = fetchFromRegistry(t.Repository, digest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch artifact %s from %s: %w", digest, t, err)
+	}
+
+	return artifactContent, nil
+}
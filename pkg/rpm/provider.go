@@ -23,23 +23,27 @@ import (
 
 const RPMDbPath = "/var/lib/rpm"
 
-func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, error) {
+func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (metadata.Metadata, []common.Warning, error) {
+	dbPath := params.ProviderOptions.RPM.DbPath
+	if dbPath == "" {
+		dbPath = RPMDbPath
+	}
 
-	absPath, err := dli.AbsolutePath(RPMDbPath)
+	absPath, err := dli.AbsolutePath(dbPath)
 	if err != nil {
-		return metadata.Metadata{}, fmt.Errorf("absolute path for rpm database: %w", err)
+		return metadata.Metadata{}, nil, fmt.Errorf("absolute path for rpm database: %w", err)
 	}
 
 	exists, err := exists(path.Join(absPath, "Packages"))
 	if err != nil {
-		return metadata.Metadata{}, fmt.Errorf("rpm could not find existance of path: %w", err)
+		return metadata.Metadata{}, nil, fmt.Errorf("rpm could not find existance of path: %w", err)
 	}
 	if !exists {
-		return md, nil
+		return md, nil, nil
 	}
 
 	if !isRPMInstalled() {
-		return metadata.Metadata{}, fmt.Errorf("an rpm database exists at %s but rpm is not installed and available on your path: %w", RPMDbPath, err)
+		return metadata.Metadata{}, nil, fmt.Errorf("an rpm database exists at %s but rpm is not installed and available on your path: %w", dbPath, err)
 	}
 
 	query := QueryFormat()
@@ -54,12 +58,12 @@ func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (m
 	err = cmd.Run()
 
 	if err != nil {
-		return metadata.Metadata{},
+		return metadata.Metadata{}, nil,
 			fmt.Errorf("failed to execute rpm at path, %s, with query, %s: %w", absPath, query, err)
 	}
 
 	if strings.TrimSpace(stdOutBuffer.String()) == "" {
-		return metadata.Metadata{}, fmt.Errorf("no rpm packages data found")
+		return metadata.Metadata{}, nil, fmt.Errorf("no rpm packages data found")
 	}
 
 	allPackagesDetails := strings.Split(strings.TrimSpace(stdOutBuffer.String()), "\n")
@@ -80,7 +84,7 @@ func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (m
 
 	version, err := common.Digest(sourceMetadata)
 	if err != nil {
-		return metadata.Metadata{}, fmt.Errorf("could not get digest for source metadata: %w", err)
+		return metadata.Metadata{}, nil, fmt.Errorf("could not get digest for source metadata: %w", err)
 	}
 
 	md.Dependencies = append(md.Dependencies, metadata.Dependency{
@@ -94,7 +98,7 @@ func Provider(dli image.Image, params common.RunParams, md metadata.Metadata) (m
 		},
 	})
 
-	return md, nil
+	return md, nil, nil
 }
 
 func isRPMInstalled() bool {
@@ -69,7 +69,7 @@ var _ = Describe("Pkg/Rpm/Provider", func() {
 	})
 
 	It("should generate list of dependencies", func() {
-		md, err := rpm.Provider(MockImage{"../../test/integration/assets/rpm"}, common.RunParams{}, metadata.Metadata{})
+		md, _, err := rpm.Provider(MockImage{"../../test/integration/assets/rpm"}, common.RunParams{}, metadata.Metadata{})
 
 		Expect(err).ToNot(HaveOccurred())
 		packages := md.Dependencies[0].Source.Metadata.(metadata.RpmPackageListSourceMetadata).Packages
@@ -92,7 +92,7 @@ var _ = Describe("Pkg/Rpm/Provider", func() {
 		defer func() {
 			_ = os.Remove(tempDirPath)
 		}()
-		packages, err := rpm.Provider(MockImage{tempDirPath}, common.RunParams{}, metadata.Metadata{Dependencies: []metadata.Dependency{{
+		packages, _, err := rpm.Provider(MockImage{tempDirPath}, common.RunParams{}, metadata.Metadata{Dependencies: []metadata.Dependency{{
 			Type: "Do not touch this one!!!!!",
 		}}})
 		Expect(err).NotTo(HaveOccurred())
@@ -109,7 +109,7 @@ var _ = Describe("Pkg/Rpm/Provider", func() {
 		defer func() {
 			_ = os.Remove(tempDirPath)
 		}()
-		packages, err := rpm.Provider(test_utils.NewMockImageWithPath(tempDirPath), common.RunParams{}, metadata.Metadata{
+		packages, _, err := rpm.Provider(test_utils.NewMockImageWithPath(tempDirPath), common.RunParams{}, metadata.Metadata{
 			Dependencies: []metadata.Dependency{{
 				Type: "Do not touch this one!!!!!",
 			}},
@@ -129,7 +129,7 @@ var _ = Describe("Pkg/Rpm/Provider", func() {
 			Expect(os.Setenv("PATH", PATH)).ToNot(HaveOccurred())
 		}()
 
-		_, err := rpm.Provider(MockImage{"../../test/integration/assets/rpm"}, common.RunParams{}, metadata.Metadata{})
+		_, _, err := rpm.Provider(MockImage{"../../test/integration/assets/rpm"}, common.RunParams{}, metadata.Metadata{})
 
 		Expect(err).To(MatchError(SatisfyAll(
 			ContainSubstring("an rpm database exists at"),
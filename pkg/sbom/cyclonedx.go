@@ -0,0 +1,89 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package sbom
+
+import (
+	"time"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+type CycloneDXDocument struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Metadata    CycloneDXMetadata    `json:"metadata"`
+	Components  []CycloneDXComponent `json:"components"`
+}
+
+type CycloneDXMetadata struct {
+	Timestamp string          `json:"timestamp"`
+	Tools     []CycloneDXTool `json:"tools"`
+}
+
+type CycloneDXTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type CycloneDXComponent struct {
+	Type               string                       `json:"type"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	PURL               string                       `json:"purl,omitempty"`
+	Hashes             []CycloneDXHash              `json:"hashes,omitempty"`
+	Licenses           []CycloneDXLicenseChoice     `json:"licenses,omitempty"`
+	ExternalReferences []CycloneDXExternalReference `json:"externalReferences,omitempty"`
+}
+
+type CycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type CycloneDXLicenseChoice struct {
+	Expression string `json:"expression"`
+}
+
+type CycloneDXExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+func NewCycloneDXDocument(md metadata.Metadata, tool Tool, created time.Time) CycloneDXDocument {
+	document := CycloneDXDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.4",
+		Version:     1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: created.UTC().Format(time.RFC3339),
+			Tools:     []CycloneDXTool{{Name: tool.Name, Version: tool.Version}},
+		},
+		Components: []CycloneDXComponent{},
+	}
+
+	for _, c := range components(md) {
+		component := CycloneDXComponent{
+			Type:    "library",
+			Name:    c.name,
+			Version: c.version,
+			PURL:    c.purl,
+		}
+		if c.sha256 != "" {
+			component.Hashes = append(component.Hashes, CycloneDXHash{Alg: "SHA-256", Content: c.sha256})
+		}
+		if c.sha512 != "" {
+			component.Hashes = append(component.Hashes, CycloneDXHash{Alg: "SHA-512", Content: c.sha512})
+		}
+		if c.license != "" {
+			component.Licenses = []CycloneDXLicenseChoice{{Expression: c.license}}
+		}
+		if c.downloadLocation != "" {
+			component.ExternalReferences = []CycloneDXExternalReference{{Type: "distribution", URL: c.downloadLocation}}
+		}
+		document.Components = append(document.Components, component)
+	}
+
+	return document
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+const (
+	DeplabFormat    = "deplab"
+	SPDXFormat      = "spdx"
+	CycloneDXFormat = "cyclonedx"
+
+	DeplabMediaType    = "application/vnd.deplab.metadata.v1+json"
+	SPDXMediaType      = "application/spdx+json"
+	CycloneDXMediaType = "application/vnd.cyclonedx+json"
+)
+
+var Formats = []string{DeplabFormat, SPDXFormat, CycloneDXFormat}
+
+// Tool identifies the program generating the SBOM.
+type Tool struct {
+	Name    string
+	Version string
+	URL     string
+}
+
+// Generate encodes the metadata in the given format and returns it with its
+// media type.
+func Generate(md metadata.Metadata, format string, tool Tool, created time.Time) ([]byte, string, error) {
+	var (
+		document  interface{}
+		mediaType string
+	)
+
+	switch format {
+	case DeplabFormat, "":
+		document, mediaType = md, DeplabMediaType
+	case SPDXFormat:
+		document, mediaType = NewSPDXDocument(md, tool, created), SPDXMediaType
+	case CycloneDXFormat:
+		document, mediaType = NewCycloneDXDocument(md, tool, created), CycloneDXMediaType
+	default:
+		return nil, "", fmt.Errorf("unsupported sbom format %s, supported formats are: %s", format, strings.Join(Formats, ", "))
+	}
+
+	content, err := json.Marshal(document)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not encode %s sbom: %w", format, err)
+	}
+
+	return content, mediaType, nil
+}
+
+// component is a format independent view of a package in the metadata.
+type component struct {
+	name             string
+	version          string
+	purl             string
+	license          string
+	downloadLocation string
+	sha256           string
+	sha512           string
+}
+
+func components(md metadata.Metadata) []component {
+	distro := strings.ToLower(md.Base["id"])
+
+	var components []component
+	for _, dependency := range md.Dependencies {
+		switch sourceMetadata := dependency.Source.Metadata.(type) {
+		case metadata.DebianPackageListSourceMetadata:
+			for _, p := range sourceMetadata.Packages {
+				components = append(components, component{
+					name:    p.Package,
+					version: p.Version,
+					purl:    packageURL("deb", orDefault(distro, "debian"), p.Package, p.Version, p.Architecture),
+				})
+			}
+		case metadata.RpmPackageListSourceMetadata:
+			for _, p := range sourceMetadata.Packages {
+				components = append(components, component{
+					name:    p.Package,
+					version: p.Version,
+					purl:    packageURL("rpm", orDefault(distro, "rpm"), p.Package, p.Version, p.Architecture),
+					license: p.License,
+				})
+			}
+		case metadata.BuildpackBOMSourceMetadata:
+			for _, bom := range sourceMetadata.BillOfMaterials {
+				var licenses []string
+				for _, l := range bom.Licenses {
+					licenses = append(licenses, l.Type)
+				}
+				components = append(components, component{
+					name:             bom.Name,
+					version:          bom.Version,
+					purl:             bom.PURL,
+					license:          strings.Join(licenses, " AND "),
+					downloadLocation: bom.URI,
+					sha256:           bom.Sha256,
+				})
+			}
+			for _, c := range sourceMetadata.Components {
+				components = append(components, component{
+					name:    c.Name,
+					version: c.Version,
+					purl:    c.PURL,
+					license: strings.Join(c.Licenses, " AND "),
+					sha256:  c.Sha256,
+				})
+			}
+		case metadata.GitSourceMetadata:
+			commit, _ := dependency.Source.Version["commit"].(string)
+			components = append(components, component{
+				name:             sourceMetadata.URL,
+				version:          commit,
+				downloadLocation: "git+" + sourceMetadata.URL + "@" + commit,
+			})
+		case metadata.HgSourceMetadata:
+			changeset, _ := dependency.Source.Version["changeset"].(string)
+			components = append(components, component{
+				name:             sourceMetadata.URL,
+				version:          changeset,
+				downloadLocation: "hg+" + sourceMetadata.URL + "@" + changeset,
+			})
+		case metadata.SvnSourceMetadata:
+			revision, _ := dependency.Source.Version["revision"].(string)
+			components = append(components, component{
+				name:             sourceMetadata.URL,
+				version:          revision,
+				downloadLocation: "svn+" + sourceMetadata.URL + "@" + revision,
+			})
+		case metadata.GoModuleSourceMetadata:
+			version, _ := dependency.Source.Version["version"].(string)
+			components = append(components, component{
+				name:    sourceMetadata.Path,
+				version: version,
+				// module paths are made of purl safe characters, the
+				// namespace keeps its slashes
+				purl: "pkg:golang/" + sourceMetadata.Path + "@" + url.PathEscape(version),
+			})
+		case metadata.ArchiveSourceMetadata:
+			components = append(components, component{
+				name:             orDefault(sourceMetadata.Name, archiveName(sourceMetadata.URL)),
+				version:          sourceMetadata.Version,
+				license:          sourceMetadata.License,
+				downloadLocation: sourceMetadata.URL,
+				sha256:           sourceMetadata.Sha256,
+				sha512:           sourceMetadata.Sha512,
+			})
+		case metadata.ImageSourceMetadata:
+			digest, _ := dependency.Source.Version["digest"].(string)
+			repository := imageRepository(sourceMetadata.Image)
+			components = append(components, component{
+				name:    repository,
+				version: digest,
+				purl:    imagePackageURL(repository, digest),
+			})
+		case metadata.FileSourceMetadata:
+			for _, f := range sourceMetadata.Files {
+				components = append(components, component{
+					name:             f.Path,
+					license:          sourceMetadata.License,
+					downloadLocation: sourceMetadata.Origin,
+					sha256:           f.Sha256,
+				})
+			}
+		}
+	}
+
+	return components
+}
+
+func packageURL(packageType, namespace, name, version, architecture string) string {
+	purl := fmt.Sprintf("pkg:%s/%s/%s@%s", packageType, url.PathEscape(namespace), url.PathEscape(name), url.PathEscape(version))
+	if architecture != "" {
+		purl += "?arch=" + url.QueryEscape(architecture)
+	}
+	return purl
+}
+
+// imageRepository removes the digest and tag from an image reference.
+func imageRepository(reference string) string {
+	if i := strings.Index(reference, "@"); i >= 0 {
+		reference = reference[:i]
+	}
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		reference = reference[:i]
+	}
+	return reference
+}
+
+// imagePackageURL is the oci purl of an image, named after the last element
+// of its repository.
+func imagePackageURL(repository, digest string) string {
+	if digest == "" {
+		return ""
+	}
+	return fmt.Sprintf("pkg:oci/%s@%s?repository_url=%s", url.PathEscape(path.Base(repository)), url.PathEscape(digest), url.QueryEscape(repository))
+}
+
+func archiveName(archiveURL string) string {
+	u, err := url.Parse(archiveURL)
+	if err != nil || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return archiveURL
+	}
+	return path.Base(u.Path)
+}
+
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package sbom_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/sbom"
+)
+
+var _ = Describe("SBOM", func() {
+	tool := Tool{Name: "deplab", Version: "1.2.3", URL: "https://github.com/vmware-tanzu/dependency-labeler"}
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	md := metadata.Metadata{
+		Base: metadata.Base{"id": "ubuntu"},
+		Dependencies: []metadata.Dependency{
+			{
+				Type: metadata.DebianPackageListSourceType,
+				Source: metadata.Source{
+					Type: "inline",
+					Metadata: metadata.DebianPackageListSourceMetadata{
+						Packages: []metadata.DpkgPackage{
+							{Package: "zlib1g", Version: "1:1.2.11.dfsg-0ubuntu2", Architecture: "amd64"},
+						},
+					},
+				},
+			},
+			{
+				Type: metadata.PackageType,
+				Source: metadata.Source{
+					Type:    metadata.GitSourceType,
+					Version: map[string]interface{}{"commit": "abc123"},
+					Metadata: metadata.GitSourceMetadata{
+						URL: "https://example.com/repo.git",
+					},
+				},
+			},
+			{
+				Type: metadata.PackageType,
+				Source: metadata.Source{
+					Type: metadata.ArchiveType,
+					Metadata: metadata.ArchiveSourceMetadata{
+						URL:     "https://example.com/source.tgz",
+						Version: "1.0.0",
+						License: "MIT",
+						Sha256:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+					},
+				},
+			},
+		},
+	}
+
+	Describe("Generate", func() {
+		It("encodes deplab metadata as it is", func() {
+			content, mediaType, err := Generate(md, DeplabFormat, tool, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mediaType).To(Equal(DeplabMediaType))
+			expected, err := json.Marshal(md)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(MatchJSON(expected))
+		})
+
+		It("encodes an SPDX document", func() {
+			content, mediaType, err := Generate(md, SPDXFormat, tool, created)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mediaType).To(Equal(SPDXMediaType))
+
+			var document SPDXDocument
+			Expect(json.Unmarshal(content, &document)).To(Succeed())
+
+			Expect(document.SPDXVersion).To(Equal("SPDX-2.3"))
+			Expect(document.CreationInfo).To(Equal(SPDXCreationInfo{
+				Created:  "2020-01-02T03:04:05Z",
+				Creators: []string{"Tool: deplab-1.2.3"},
+			}))
+			Expect(document.DocumentNamespace).To(HavePrefix("https://github.com/vmware-tanzu/dependency-labeler/spdx/"))
+			Expect(document.Packages).To(Equal([]SPDXPackage{
+				{
+					SPDXID:           "SPDXRef-Package-1",
+					Name:             "zlib1g",
+					VersionInfo:      "1:1.2.11.dfsg-0ubuntu2",
+					DownloadLocation: "NOASSERTION",
+					LicenseDeclared:  "NOASSERTION",
+					ExternalRefs: []SPDXExternalRef{{
+						ReferenceCategory: "PACKAGE-MANAGER",
+						ReferenceType:     "purl",
+						ReferenceLocator:  "pkg:deb/ubuntu/zlib1g@1:1.2.11.dfsg-0ubuntu2?arch=amd64",
+					}},
+				},
+				{
+					SPDXID:           "SPDXRef-Package-2",
+					Name:             "https://example.com/repo.git",
+					VersionInfo:      "abc123",
+					DownloadLocation: "git+https://example.com/repo.git@abc123",
+					LicenseDeclared:  "NOASSERTION",
+				},
+				{
+					SPDXID:           "SPDXRef-Package-3",
+					Name:             "source.tgz",
+					VersionInfo:      "1.0.0",
+					DownloadLocation: "https://example.com/source.tgz",
+					LicenseDeclared:  "MIT",
+					Checksums: []SPDXChecksum{{
+						Algorithm:     "SHA256",
+						ChecksumValue: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+					}},
+				},
+			}))
+			Expect(document.Relationships).To(HaveLen(3))
+			Expect(document.Relationships[0]).To(Equal(SPDXRelationship{
+				SPDXElementID:      "SPDXRef-DOCUMENT",
+				RelationshipType:   "DESCRIBES",
+				RelatedSPDXElement: "SPDXRef-Package-1",
+			}))
+		})
+
+		It("encodes a CycloneDX document", func() {
+			content, mediaType, err := Generate(md, CycloneDXFormat, tool, created)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mediaType).To(Equal(CycloneDXMediaType))
+
+			var document CycloneDXDocument
+			Expect(json.Unmarshal(content, &document)).To(Succeed())
+
+			Expect(document.BOMFormat).To(Equal("CycloneDX"))
+			Expect(document.Metadata.Tools).To(Equal([]CycloneDXTool{{Name: "deplab", Version: "1.2.3"}}))
+			Expect(document.Components).To(HaveLen(3))
+			Expect(document.Components[0]).To(Equal(CycloneDXComponent{
+				Type:    "library",
+				Name:    "zlib1g",
+				Version: "1:1.2.11.dfsg-0ubuntu2",
+				PURL:    "pkg:deb/ubuntu/zlib1g@1:1.2.11.dfsg-0ubuntu2?arch=amd64",
+			}))
+			Expect(document.Components[1].ExternalReferences).To(Equal([]CycloneDXExternalReference{{
+				Type: "distribution",
+				URL:  "git+https://example.com/repo.git@abc123",
+			}}))
+			Expect(document.Components[2].Hashes).To(Equal([]CycloneDXHash{{
+				Alg:     "SHA-256",
+				Content: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			}}))
+			Expect(document.Components[2].Licenses).To(Equal([]CycloneDXLicenseChoice{{Expression: "MIT"}}))
+		})
+
+		It("describes vcs dependencies other than git", func() {
+			vcsMetadata := metadata.Metadata{Dependencies: []metadata.Dependency{
+				{
+					Type: metadata.PackageType,
+					Source: metadata.Source{
+						Type:     metadata.HgSourceType,
+						Version:  map[string]interface{}{"changeset": "0123456789ab"},
+						Metadata: metadata.HgSourceMetadata{URL: "https://hg.example.com/repo"},
+					},
+				},
+				{
+					Type: metadata.PackageType,
+					Source: metadata.Source{
+						Type:     metadata.GoModuleSourceType,
+						Version:  map[string]interface{}{"version": "v0.3.7"},
+						Metadata: metadata.GoModuleSourceMetadata{Path: "golang.org/x/text", Hash: "h1:olpwvP2KacW1ZWvsR7uQhoyTYvKAupfQrRGBFM352Gk="},
+					},
+				},
+			}}
+
+			content, _, err := Generate(vcsMetadata, CycloneDXFormat, tool, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			var document CycloneDXDocument
+			Expect(json.Unmarshal(content, &document)).To(Succeed())
+
+			Expect(document.Components).To(Equal([]CycloneDXComponent{
+				{
+					Type:               "library",
+					Name:               "https://hg.example.com/repo",
+					Version:            "0123456789ab",
+					ExternalReferences: []CycloneDXExternalReference{{Type: "distribution", URL: "hg+https://hg.example.com/repo@0123456789ab"}},
+				},
+				{
+					Type:    "library",
+					Name:    "golang.org/x/text",
+					Version: "v0.3.7",
+					PURL:    "pkg:golang/golang.org/x/text@v0.3.7",
+				},
+			}))
+		})
+
+		It("records the components of buildpack sbom layers", func() {
+			buildpackMetadata := metadata.Metadata{Dependencies: []metadata.Dependency{{
+				Type: metadata.BuildpackMetadataType,
+				Source: metadata.Source{
+					Type: "inline",
+					Metadata: metadata.BuildpackBOMSourceMetadata{Components: []metadata.BuildpackComponent{{
+						Name:     "express",
+						Version:  "4.17.3",
+						PURL:     "pkg:npm/express@4.17.3",
+						Licenses: []string{"MIT", "ISC"},
+						Sha256:   "abc",
+					}}},
+				},
+			}}}
+
+			content, _, err := Generate(buildpackMetadata, CycloneDXFormat, tool, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			var document CycloneDXDocument
+			Expect(json.Unmarshal(content, &document)).To(Succeed())
+
+			Expect(document.Components).To(HaveLen(1))
+			Expect(document.Components[0].PURL).To(Equal("pkg:npm/express@4.17.3"))
+			Expect(document.Components[0].Licenses).To(Equal([]CycloneDXLicenseChoice{{Expression: "MIT AND ISC"}}))
+		})
+
+		It("records the typed fields of buildpack bill of materials entries", func() {
+			buildpackMetadata := metadata.Metadata{Dependencies: []metadata.Dependency{{
+				Type: metadata.BuildpackMetadataType,
+				Source: metadata.Source{
+					Type: "inline",
+					Metadata: metadata.BuildpackBOMSourceMetadata{BillOfMaterials: []metadata.BuildpackBOM{{
+						Name:     "jdk",
+						Version:  "11.0.14",
+						URI:      "https://example.com/jdk.tar.gz",
+						Sha256:   "abc",
+						Licenses: []metadata.BuildpackLicense{{Type: "GPL-2.0 WITH Classpath-exception-2.0"}},
+						PURL:     "pkg:generic/bellsoft-jdk@11.0.14",
+					}}},
+				},
+			}}}
+
+			content, _, err := Generate(buildpackMetadata, CycloneDXFormat, tool, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			var document CycloneDXDocument
+			Expect(json.Unmarshal(content, &document)).To(Succeed())
+
+			Expect(document.Components).To(HaveLen(1))
+			Expect(document.Components[0].PURL).To(Equal("pkg:generic/bellsoft-jdk@11.0.14"))
+			Expect(document.Components[0].Hashes).To(Equal([]CycloneDXHash{{Alg: "SHA-256", Content: "abc"}}))
+			Expect(document.Components[0].Licenses).To(Equal([]CycloneDXLicenseChoice{{Expression: "GPL-2.0 WITH Classpath-exception-2.0"}}))
+			Expect(document.Components[0].ExternalReferences).To(Equal([]CycloneDXExternalReference{{Type: "distribution", URL: "https://example.com/jdk.tar.gz"}}))
+		})
+
+		It("records image sources with an oci purl", func() {
+			imageMetadata := metadata.Metadata{Dependencies: []metadata.Dependency{{
+				Type: metadata.PackageType,
+				Source: metadata.Source{
+					Type:     metadata.ImageSourceType,
+					Version:  map[string]interface{}{"digest": "sha256:abc"},
+					Metadata: metadata.ImageSourceMetadata{Image: "registry.example.com:5000/org/source:v1@sha256:abc"},
+				},
+			}}}
+
+			content, _, err := Generate(imageMetadata, CycloneDXFormat, tool, created)
+			Expect(err).ToNot(HaveOccurred())
+
+			var document CycloneDXDocument
+			Expect(json.Unmarshal(content, &document)).To(Succeed())
+
+			Expect(document.Components).To(Equal([]CycloneDXComponent{{
+				Type:    "library",
+				Name:    "registry.example.com:5000/org/source",
+				Version: "sha256:abc",
+				PURL:    "pkg:oci/source@sha256:abc?repository_url=registry.example.com%3A5000%2Forg%2Fsource",
+			}}))
+		})
+
+		It("returns an error for an unsupported format", func() {
+			_, _, err := Generate(md, "swid", tool, created)
+			Expect(err).To(MatchError(ContainSubstring("unsupported sbom format swid")))
+		})
+	})
+})
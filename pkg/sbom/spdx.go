@@ -0,0 +1,111 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package sbom
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+)
+
+const noAssertion = "NOASSERTION"
+
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	Checksums        []SPDXChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+}
+
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func NewSPDXDocument(md metadata.Metadata, tool Tool, created time.Time) SPDXDocument {
+	// the namespace has to be unique for each document, the digest of the
+	// metadata identifies it without depending on the time it was created
+	digest, _ := common.Digest(md)
+
+	document := SPDXDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              fmt.Sprintf("%s-sbom", tool.Name),
+		DocumentNamespace: fmt.Sprintf("%s/spdx/%s", tool.URL, digest),
+		CreationInfo: SPDXCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: %s-%s", tool.Name, tool.Version)},
+		},
+		Packages:      []SPDXPackage{},
+		Relationships: []SPDXRelationship{},
+	}
+
+	for i, c := range components(md) {
+		spdxID := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+
+		pkg := SPDXPackage{
+			SPDXID:           spdxID,
+			Name:             c.name,
+			VersionInfo:      c.version,
+			DownloadLocation: orDefault(c.downloadLocation, noAssertion),
+			LicenseDeclared:  orDefault(c.license, noAssertion),
+		}
+		if c.sha256 != "" {
+			pkg.Checksums = append(pkg.Checksums, SPDXChecksum{Algorithm: "SHA256", ChecksumValue: c.sha256})
+		}
+		if c.sha512 != "" {
+			pkg.Checksums = append(pkg.Checksums, SPDXChecksum{Algorithm: "SHA512", ChecksumValue: c.sha512})
+		}
+		if c.purl != "" {
+			pkg.ExternalRefs = []SPDXExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  c.purl,
+			}}
+		}
+
+		document.Packages = append(document.Packages, pkg)
+		document.Relationships = append(document.Relationships, SPDXRelationship{
+			SPDXElementID:      document.SPDXID,
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: spdxID,
+		})
+	}
+
+	return document
+}
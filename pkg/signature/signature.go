@@ -0,0 +1,179 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+const (
+	MediaType   = "application/vnd.deplab.signature.v1+json"
+	PayloadType = "https://github.com/vmware-tanzu/dependency-labeler/signature@v1"
+
+	// ImageTarget signs the digest of the labeled image.
+	ImageTarget = "image"
+	// MetadataTarget signs the digest of the io.deplab.metadata label.
+	MetadataTarget = "metadata"
+
+	ECDSAAlgorithm   = "ecdsa-p256-sha256"
+	Ed25519Algorithm = "ed25519"
+)
+
+var Targets = []string{ImageTarget, MetadataTarget}
+
+// Payload is the signed statement: which digest was signed, and by whom.
+type Payload struct {
+	Type    string  `json:"type"`
+	Subject Subject `json:"subject"`
+	Signer  string  `json:"signer,omitempty"`
+	Created string  `json:"created"`
+}
+
+type Subject struct {
+	Target string `json:"target"`
+	Name   string `json:"name,omitempty"`
+	Digest string `json:"digest"`
+}
+
+// Envelope carries the payload exactly as it was signed, with its signature.
+type Envelope struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+}
+
+func NewPayload(target, name, digest, signer string, created time.Time) Payload {
+	return Payload{
+		Type: PayloadType,
+		Subject: Subject{
+			Target: target,
+			Name:   name,
+			Digest: digest,
+		},
+		Signer:  signer,
+		Created: created.UTC().Format(time.RFC3339),
+	}
+}
+
+// Digest returns the sha256 digest of the content, as signed for the
+// metadata target.
+func Digest(content []byte) (string, error) {
+	hash, _, err := v1.SHA256(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), nil
+}
+
+func Sign(payload Payload, key crypto.Signer) (Envelope, error) {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("could not encode signature payload: %w", err)
+	}
+
+	keyID, err := KeyID(key.Public())
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	var (
+		signature []byte
+		algorithm string
+	)
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		sum := sha256.Sum256(encodedPayload)
+		signature, err = key.Sign(rand.Reader, sum[:], crypto.SHA256)
+		algorithm = ECDSAAlgorithm
+	case ed25519.PrivateKey:
+		signature, err = key.Sign(rand.Reader, encodedPayload, crypto.Hash(0))
+		algorithm = Ed25519Algorithm
+	default:
+		return Envelope{}, fmt.Errorf("unsupported private key algorithm, only ecdsa and ed25519 keys are supported")
+	}
+	if err != nil {
+		return Envelope{}, fmt.Errorf("could not sign payload: %w", err)
+	}
+
+	return Envelope{
+		Payload:   encodedPayload,
+		Signature: signature,
+		Algorithm: algorithm,
+		KeyID:     keyID,
+	}, nil
+}
+
+// Verify checks the signature of the envelope against the public key and
+// returns the signed payload.
+func Verify(envelope Envelope, key crypto.PublicKey) (Payload, error) {
+	var verified bool
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(envelope.Payload)
+		verified = envelope.Algorithm == ECDSAAlgorithm && ecdsa.VerifyASN1(key, sum[:], envelope.Signature)
+	case ed25519.PublicKey:
+		verified = envelope.Algorithm == Ed25519Algorithm && ed25519.Verify(key, envelope.Payload, envelope.Signature)
+	default:
+		return Payload{}, fmt.Errorf("unsupported public key algorithm, only ecdsa and ed25519 keys are supported")
+	}
+
+	if !verified {
+		return Payload{}, fmt.Errorf("signature does not match the public key")
+	}
+
+	var payload Payload
+	err := json.Unmarshal(envelope.Payload, &payload)
+	if err != nil {
+		return Payload{}, fmt.Errorf("could not decode signature payload: %w", err)
+	}
+
+	if payload.Type != PayloadType {
+		return Payload{}, fmt.Errorf("unsupported signature payload type %s", payload.Type)
+	}
+
+	return payload, nil
+}
+
+func WriteEnvelopeFile(envelope Envelope, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create signature file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	err = json.NewEncoder(f).Encode(envelope)
+	if err != nil {
+		return fmt.Errorf("could not write signature to file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func ReadEnvelopeFile(path string) (Envelope, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("could not open signature file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var envelope Envelope
+	err = json.NewDecoder(f).Decode(&envelope)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("could not parse signature file %s: %w", path, err)
+	}
+
+	return envelope, nil
+}
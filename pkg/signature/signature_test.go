@@ -0,0 +1,142 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package signature_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/vmware-tanzu/dependency-labeler/pkg/signature"
+)
+
+var _ = Describe("Signature", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "deplab-signature")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeKeys := func(private crypto.Signer) (string, string) {
+		privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+		Expect(err).ToNot(HaveOccurred())
+		publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+		Expect(err).ToNot(HaveOccurred())
+
+		privatePath := filepath.Join(dir, "key.pem")
+		publicPath := filepath.Join(dir, "key.pub")
+		Expect(ioutil.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600)).To(Succeed())
+		Expect(ioutil.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644)).To(Succeed())
+
+		return privatePath, publicPath
+	}
+
+	payload := NewPayload(ImageTarget, "example.com/image:tag", "sha256:abc", "ci@example.com", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	for _, algorithm := range []string{ECDSAAlgorithm, Ed25519Algorithm} {
+		algorithm := algorithm
+
+		Context("with an "+algorithm+" key", func() {
+			var privatePath, publicPath string
+
+			BeforeEach(func() {
+				var private crypto.Signer
+				if algorithm == ECDSAAlgorithm {
+					private, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				} else {
+					_, private, _ = ed25519.GenerateKey(rand.Reader)
+				}
+				privatePath, publicPath = writeKeys(private)
+			})
+
+			It("signs a payload which verifies against the public key", func() {
+				privateKey, err := LoadPrivateKey(privatePath)
+				Expect(err).ToNot(HaveOccurred())
+				publicKey, err := LoadPublicKey(publicPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				envelope, err := Sign(payload, privateKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(envelope.Algorithm).To(Equal(algorithm))
+
+				keyID, err := KeyID(publicKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(envelope.KeyID).To(Equal(keyID))
+
+				By("round tripping the envelope through a file")
+				envelopePath := filepath.Join(dir, "signature.json")
+				Expect(WriteEnvelopeFile(envelope, envelopePath)).To(Succeed())
+				envelope, err = ReadEnvelopeFile(envelopePath)
+				Expect(err).ToNot(HaveOccurred())
+
+				verifiedPayload, err := Verify(envelope, publicKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(verifiedPayload).To(Equal(payload))
+			})
+
+			It("does not verify a tampered payload", func() {
+				privateKey, err := LoadPrivateKey(privatePath)
+				Expect(err).ToNot(HaveOccurred())
+				publicKey, err := LoadPublicKey(publicPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				envelope, err := Sign(payload, privateKey)
+				Expect(err).ToNot(HaveOccurred())
+
+				tampered := payload
+				tampered.Subject.Digest = "sha256:def"
+				envelope.Payload, err = json.Marshal(tampered)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = Verify(envelope, publicKey)
+				Expect(err).To(MatchError(ContainSubstring("does not match the public key")))
+			})
+		})
+	}
+
+	It("does not verify against another key", func() {
+		private, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+		envelope, err := Sign(payload, private)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = Verify(envelope, other.Public())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects keys of unsupported algorithms", func() {
+		private, err := rsa.GenerateKey(rand.Reader, 1024)
+		Expect(err).ToNot(HaveOccurred())
+		privatePath, publicPath := writeKeys(private)
+
+		_, err = LoadPrivateKey(privatePath)
+		Expect(err).To(MatchError(ContainSubstring("only ecdsa and ed25519 keys are supported")))
+
+		_, err = LoadPublicKey(publicPath)
+		Expect(err).To(MatchError(ContainSubstring("only ecdsa and ed25519 keys are supported")))
+	})
+
+	It("digests content with sha256", func() {
+		Expect(Digest([]byte("{}"))).To(Equal("sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"))
+	})
+})
@@ -4,7 +4,10 @@
 package integration_test
 
 import (
+	"io/ioutil"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
 
@@ -76,9 +79,175 @@ var _ = Describe("deplab additional-source-url", func() {
 				server.Close()
 			})
 		})
+
+		Context("when I supply the attributes of the archive in the url fragment", func() {
+			var address string
+			BeforeEach(func() {
+				server = startServer(
+					ghttp.RespondWith(http.StatusOK, []byte("")))
+				address = server.URL() + "/foo/bar/file.zip"
+				additionalArguments = []string{"--additional-source-url", address + "#sha256=" + archiveSha256 + "&license=MIT&name=bar&version=1.0.0"}
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			It("records the attributes with the url", func() {
+				archiveDependencies := selectArchiveDependencies(metadataLabel.Dependencies)
+				Expect(archiveDependencies).To(HaveLen(1))
+
+				archiveSourceMetadata := archiveDependencies[0].Source.Metadata.(map[string]interface{})
+				Expect(archiveSourceMetadata).To(Equal(map[string]interface{}{
+					"url":     address,
+					"sha256":  archiveSha256,
+					"license": "MIT",
+					"name":    "bar",
+					"version": "1.0.0",
+				}))
+			})
+		})
+
+		Context("when I supply an archive cache", func() {
+			var (
+				address  string
+				cacheDir string
+			)
+			BeforeEach(func() {
+				var err error
+				cacheDir, err = ioutil.TempDir("", "deplab-archive-cache-")
+				Expect(err).ToNot(HaveOccurred())
+
+				server = startServer(
+					ghttp.RespondWith(http.StatusOK, []byte("test")))
+				address = server.URL() + "/foo/bar/file.zip"
+				additionalArguments = []string{"--additional-source-url", address, "--archive-cache", cacheDir}
+			})
+
+			AfterEach(func() {
+				server.Close()
+				Expect(os.RemoveAll(cacheDir)).To(Succeed())
+			})
+
+			It("downloads the archive and records its sha256 digest", func() {
+				archiveDependencies := selectArchiveDependencies(metadataLabel.Dependencies)
+				Expect(archiveDependencies).To(HaveLen(1))
+
+				archiveSourceMetadata := archiveDependencies[0].Source.Metadata.(map[string]interface{})
+				Expect(archiveSourceMetadata["sha256"]).To(Equal(archiveSha256))
+			})
+		})
+	})
+
+	Context("when the url has no known extension", func() {
+		var server *ghttp.Server
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("recognizes the archive from the content type of the response", func() {
+			server = startServer(
+				ghttp.RespondWith(http.StatusOK, []byte(""), http.Header{"Content-Type": []string{"application/x-gzip"}}))
+
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"),
+				"--additional-source-url", server.URL()+"/example/project/archive/refs/tags/v1.2")
+
+			archiveDependencies := selectArchiveDependencies(metadataLabel.Dependencies)
+			Expect(archiveDependencies).To(HaveLen(1))
+		})
+
+		It("recognizes the archive from its content with an archive cache", func() {
+			server = startServer(
+				ghttp.RespondWith(http.StatusOK, []byte{0x1f, 0x8b, 0x08, 0x00}))
+
+			cacheDir, err := ioutil.TempDir("", "deplab-archive-cache-")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(cacheDir)
+
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"),
+				"--additional-source-url", server.URL()+"/example/project/archive/refs/tags/v1.2",
+				"--archive-cache", cacheDir)
+
+			archiveDependencies := selectArchiveDependencies(metadataLabel.Dependencies)
+			Expect(archiveDependencies).To(HaveLen(1))
+		})
+
+		It("exits with an error when the response is not an archive", func() {
+			server = startServer(
+				ghttp.RespondWith(http.StatusOK, []byte(""), http.Header{"Content-Type": []string{"text/html"}}))
+
+			_, stdErr := runDepLab([]string{
+				"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+				"--git", pathToGitRepo,
+				"--metadata-file", "doesnotmatter",
+				"--additional-source-url", server.URL() + "/example/project",
+			}, 1)
+
+			errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
+			Expect(errorOutput).To(ContainSubstring("unsupported archive type for url"))
+		})
+	})
+
+	Context("when offline", func() {
+		It("adds the archive without reaching the url", func() {
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"),
+				"--additional-source-url", "http://unreachable.invalid/foo/bar/file.zip",
+				"--offline")
+
+			archiveDependencies := selectArchiveDependencies(metadataLabel.Dependencies)
+			Expect(archiveDependencies).To(HaveLen(1))
+		})
+
+		It("still rejects urls on hosts which are not allowed", func() {
+			_, stdErr := runDepLab([]string{
+				"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+				"--git", pathToGitRepo,
+				"--metadata-file", "doesnotmatter",
+				"--additional-source-url", "http://unreachable.invalid/foo/bar/file.zip",
+				"--offline",
+				"--allowed-hosts", "*.example.com",
+			}, 1)
+
+			errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
+			Expect(errorOutput).To(ContainSubstring("host unreachable.invalid of url http://unreachable.invalid/foo/bar/file.zip is not one of the allowed hosts"))
+		})
+	})
+
+	Context("when the downloaded archive does not match the given digest", func() {
+		var server *ghttp.Server
+
+		BeforeEach(func() {
+			server = startServer(
+				ghttp.RespondWith(http.StatusOK, []byte("not the archive")))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("exits with an error", func() {
+			cacheDir, err := ioutil.TempDir("", "deplab-archive-cache-")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(cacheDir)
+
+			_, stdErr := runDepLab([]string{
+				"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+				"--git", pathToGitRepo,
+				"--metadata-file", "doesnotmatter",
+				"--additional-source-url", server.URL() + "/foo/bar/file.zip#sha256=" + archiveSha256,
+				"--archive-cache", cacheDir,
+			}, 1)
+
+			errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
+			Expect(errorOutput).To(ContainSubstring("digest mismatch"))
+		})
 	})
 })
 
+// sha256 digest of the content "test"
+const archiveSha256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
 func selectArchiveDependencies(dependencies []metadata.Dependency) []metadata.Dependency {
 	var archives []metadata.Dependency
 	for _, dependency := range dependencies {
@@ -147,6 +147,46 @@ var _ = Describe("deplab additional sources file", func() {
 			})
 		})
 
+		Context("with a version 2 json file", func() {
+			BeforeEach(func() {
+				additionalArguments = []string{"--additional-sources-file", getTestAssetPath("sources/sources-file-v2.json")}
+			})
+
+			It("adds a git dependency", func() {
+				vcsGitDependencies := selectVcsGitDependencies(selectGitDependencies(metadataLabel.Dependencies))
+				Expect(vcsGitDependencies).To(HaveLen(1))
+				Expect(vcsGitDependencies[0].Source.Version["commit"]).To(Equal("abc123"))
+			})
+		})
+
+		Context("with hg, svn and go vcs entries", func() {
+			BeforeEach(func() {
+				additionalArguments = []string{"--additional-sources-file", getTestAssetPath("sources/sources-file-v2-vcs.yml")}
+			})
+
+			It("adds a dependency of each source type", func() {
+				dependenciesByType := map[string]metadata.Dependency{}
+				for _, dependency := range metadataLabel.Dependencies {
+					dependenciesByType[dependency.Source.Type] = dependency
+				}
+
+				Expect(dependenciesByType).To(HaveKey(metadata.HgSourceType))
+				Expect(dependenciesByType[metadata.HgSourceType].Source.Version["changeset"]).To(Equal("0123456789abcdef0123456789abcdef01234567"))
+				Expect(dependenciesByType[metadata.HgSourceType].Source.Metadata).To(HaveKeyWithValue("url", "https://hg.example.com/repo"))
+
+				Expect(dependenciesByType).To(HaveKey(metadata.SvnSourceType))
+				Expect(dependenciesByType[metadata.SvnSourceType].Source.Version["revision"]).To(Equal("1234"))
+				Expect(dependenciesByType[metadata.SvnSourceType].Source.Metadata).To(HaveKeyWithValue("url", "svn://svn.example.com/repo/trunk"))
+
+				Expect(dependenciesByType).To(HaveKey(metadata.GoModuleSourceType))
+				Expect(dependenciesByType[metadata.GoModuleSourceType].Source.Version["version"]).To(Equal("v0.3.7"))
+				Expect(dependenciesByType[metadata.GoModuleSourceType].Source.Metadata).To(Equal(map[string]interface{}{
+					"path": "golang.org/x/text",
+					"hash": "h1:olpwvP2KacW1ZWvsR7uQhoyTYvKAupfQrRGBFM352Gk=",
+				}))
+			})
+		})
+
 		Context("with multiple sources files", func() {
 			var (
 				inputAdditionalSourcesPath1 string
@@ -182,6 +222,28 @@ var _ = Describe("deplab additional sources file", func() {
 		})
 	})
 
+	Context("when I supply an additional sources file with files in the image", func() {
+		It("adds a file dependency with the digests of the matching files", func() {
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/os-release-on-scratch.tgz"),
+				"--additional-sources-file", getTestAssetPath("sources/sources-file-v2-files.yml"))
+
+			fileDependencies := selectFileDependencies(metadataLabel.Dependencies)
+			Expect(fileDependencies).To(HaveLen(1))
+
+			fileSourceMetadata := fileDependencies[0].Source.Metadata.(map[string]interface{})
+			Expect(fileSourceMetadata["path"]).To(Equal("/etc/*-release"))
+			Expect(fileSourceMetadata["origin"]).To(Equal("https://example.com/os-release"))
+			Expect(fileSourceMetadata["license"]).To(Equal("MIT"))
+
+			files := fileSourceMetadata["files"].([]interface{})
+			Expect(files).To(ContainElement(SatisfyAll(
+				HaveKeyWithValue("path", "/etc/os-release"),
+				HaveKeyWithValue("size", BeNumerically(">", 0)),
+				HaveKeyWithValue("sha256", MatchRegexp("^[0-9a-f]{64}$")),
+			)))
+		})
+	})
+
 	Context("when I supply invalid additional sources file(s) as an argument", func() {
 
 		Context("with erroneous paths", func() {
@@ -211,6 +273,32 @@ var _ = Describe("deplab additional sources file", func() {
 			})
 		})
 
+		Context("with an unknown field in a version 2 file", func() {
+			It("exits with an error reporting the position of the field", func() {
+				_, stdErr := runDepLab([]string{
+					"--additional-sources-file", getTestAssetPath("sources/sources-file-v2-unknown-field.yml"),
+					"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+					"--git", pathToGitRepo,
+					"--metadata-file", "doesnotmatter17",
+				}, 1)
+				errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
+				Expect(errorOutput).To(ContainSubstring(`line 6, column 5: unknown field "refs" in vcs entry`))
+			})
+		})
+
+		Context("with files missing from the image", func() {
+			It("exits with an error", func() {
+				_, stdErr := runDepLab([]string{
+					"--additional-sources-file", getTestAssetPath("sources/sources-file-v2-missing-files.yml"),
+					"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+					"--git", pathToGitRepo,
+					"--metadata-file", "doesnotmatter18",
+				}, 1)
+				errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
+				Expect(errorOutput).To(ContainSubstring("no files in the image match /opt/vendor/does-not-exist"))
+			})
+		})
+
 		Context("with a unsupported vcs type", func() {
 			It("exits with an error", func() {
 				_, stdErr := runDepLab([]string{
@@ -220,7 +308,7 @@ var _ = Describe("deplab additional sources file", func() {
 					"--metadata-file", "doesnotmatter3",
 				}, 1)
 				errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
-				Expect(errorOutput).To(ContainSubstring("unsupported vcs protocol: hg"))
+				Expect(errorOutput).To(ContainSubstring("unsupported vcs protocol: cvs"))
 			})
 		})
 
@@ -231,6 +319,7 @@ var _ = Describe("deplab additional sources file", func() {
 					"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
 					"--git", pathToGitRepo,
 					"--metadata-file", "doesnotmatter4",
+					"--offline",
 				}, 1)
 				errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
 				Expect(errorOutput).To(ContainSubstring("unsupported extension for url"))
@@ -289,6 +378,44 @@ var _ = Describe("deplab additional sources file", func() {
 	})
 })
 
+var _ = Describe("deplab validate-sources", func() {
+	It("reports valid files", func() {
+		stdOut, _ := runDepLab([]string{
+			"validate-sources",
+			getTestAssetPath("sources/sources-file-v2.yml"),
+			getTestAssetPath("sources/sources-file-v2.json"),
+		}, 0)
+
+		Expect(string(getContentsOfReader(stdOut))).To(SatisfyAll(
+			ContainSubstring(getTestAssetPath("sources/sources-file-v2.yml")+": valid"),
+			ContainSubstring(getTestAssetPath("sources/sources-file-v2.json")+": valid"),
+		))
+	})
+
+	It("accepts archives in the formats registered in the file", func() {
+		stdOut, _ := runDepLab([]string{
+			"validate-sources",
+			getTestAssetPath("sources/sources-file-v2-formats.yml"),
+		}, 0)
+
+		Expect(string(getContentsOfReader(stdOut))).To(ContainSubstring(getTestAssetPath("sources/sources-file-v2-formats.yml") + ": valid"))
+	})
+
+	It("exits with an error for invalid files", func() {
+		stdOut, _ := runDepLab([]string{
+			"validate-sources",
+			getTestAssetPath("sources/sources-file-v2.yml"),
+			getTestAssetPath("sources/sources-file-v2-unknown-field.yml"),
+			getTestAssetPath("sources/sources-unsupported-vcs.yml"),
+		}, 1)
+
+		Expect(string(getContentsOfReader(stdOut))).To(SatisfyAll(
+			ContainSubstring(`unknown field "refs" in vcs entry`),
+			ContainSubstring("unsupported vcs protocol: cvs"),
+		))
+	})
+})
+
 func selectVcsGitDependencies(dependencies []metadata.Dependency) []metadata.Dependency {
 	var gitDependencies []metadata.Dependency
 	for _, dependency := range dependencies {
@@ -320,3 +447,13 @@ func templateAdditionalSource(path, server string) string {
 
 	return f.Name()
 }
+
+func selectFileDependencies(dependencies []metadata.Dependency) []metadata.Dependency {
+	var files []metadata.Dependency
+	for _, dependency := range dependencies {
+		if dependency.Source.Type == metadata.FileSourceType {
+			files = append(files, dependency)
+		}
+	}
+	return files
+}
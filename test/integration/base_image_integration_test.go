@@ -4,6 +4,9 @@
 package integration_test
 
 import (
+	"io/ioutil"
+	"os"
+
 	. "github.com/onsi/ginkgo/extensions/table"
 	types2 "github.com/onsi/gomega/types"
 
@@ -24,12 +27,50 @@ var _ = Describe("deplab", func() {
 			HaveKeyWithValue("id_like", "debian"),
 			HaveKeyWithValue("version_codename", "bionic"),
 			HaveKeyWithValue("pretty_name", "Ubuntu 18.04.3 LTS"),
+			HaveKeyWithValue("evidence", "/etc/os-release"),
+			HaveKeyWithValue("confidence", "high"),
+			HaveKeyWithValue("release_date", "2018-04-26"),
+			HaveKeyWithValue("end_of_standard_support", "2023-05-31"),
+			HaveKeyWithValue("end_of_extended_support", "2028-04-30"),
 		),
 		Entry("an image that doesn't have an os-release", "image-archives/all-file-types.tgz",
 			HaveKeyWithValue("name", "scratch"),
 			HaveKeyWithValue("pretty_name", "scratch"),
 			HaveKeyWithValue("version_codename", "unknown"),
 			HaveKeyWithValue("version_id", "unknown"),
+			HaveKeyWithValue("confidence", "low"),
 		),
 	)
+
+	Context("with --base-image", func() {
+		It("records the base image the image was built from", func() {
+			imagePath := getTestAssetPath("image-archives/os-release-on-scratch.tgz")
+
+			metadataLabel := runDeplabAgainstTar(imagePath, "--base-image", imagePath)
+
+			Expect(metadataLabel.Base).To(SatisfyAll(
+				HaveKeyWithValue("name", "Ubuntu"),
+				HaveKey("base_image"),
+				HaveKeyWithValue("base_image_digest", MatchRegexp(`^sha256:[0-9a-f]{64}$`)),
+				HaveKeyWithValue("base_image_source", "layers"),
+			))
+		})
+	})
+
+	Context("with --fail-on-eol", func() {
+		It("exits with an error when the base is past its end of life", func() {
+			f, err := ioutil.TempFile("", "")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(f.Name())
+
+			_, stdErr := runDepLab([]string{
+				"--image-tar", getTestAssetPath("image-archives/os-release-on-scratch.tgz"),
+				"--git", pathToGitRepo,
+				"--metadata-file", f.Name(),
+				"--fail-on-eol",
+			}, 1)
+
+			Expect(string(getContentsOfReader(stdErr))).To(ContainSubstring("failing on end of life base: base ubuntu 18.04 reached its end of life on 2023-05-31"))
+		})
+	})
 })
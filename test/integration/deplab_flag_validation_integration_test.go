@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/vmware-tanzu/dependency-labeler/pkg/common"
 	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
 
 	"github.com/onsi/gomega/ghttp"
@@ -64,7 +65,7 @@ var _ = Describe("deplab", func() {
 			}, 1)
 
 			errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
-			Expect(errorOutput).To(ContainSubstring("ERROR: requires one of --metadata-file, --dpkg-file, or --output-tar"))
+			Expect(errorOutput).To(ContainSubstring("ERROR: requires one of --metadata-file, --dpkg-file, --output-tar, or --sbom-referrer"))
 		})
 
 		It("exits with an error if both image and image-tar flags are set", func() {
@@ -222,6 +223,52 @@ var _ = Describe("deplab", func() {
 					Expect(archiveSourceMetadata["url"]).To(Equal(addresses[i]))
 				}
 			})
+
+			It("writes the warnings to the warnings file", func() {
+				d, err := ioutil.TempDir("", "deplab-integration-test-")
+				Expect(err).To(Not(HaveOccurred()))
+				defer os.RemoveAll(d)
+
+				warningsFileName := d + "/warnings.json"
+
+				_, _ = runDepLab([]string{
+					"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+					"--git", pathToGitRepo,
+					"--additional-source-url", "/foo/bar",
+					"--metadata-file", d + "/metadata-file.json",
+					"--warnings-file", warningsFileName,
+					"--ignore-validation-errors",
+				}, 0)
+
+				warningsFile, err := os.Open(warningsFileName)
+				Expect(err).ToNot(HaveOccurred())
+				defer warningsFile.Close()
+
+				var warnings []common.Warning
+				err = json.NewDecoder(warningsFile).Decode(&warnings)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(warnings).To(HaveLen(1))
+				Expect(warnings[0].Code).To(Equal(common.InvalidSourceURLWarning))
+				Expect(warnings[0].DependencyType).To(Equal(metadata.ArchiveType))
+				Expect(warnings[0].Message).To(ContainSubstring("/foo/bar"))
+			})
+
+			It("exits with an error if --fail-on-warning matches a warning", func() {
+				_, stdErr := runDepLab([]string{
+					"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+					"--git", pathToGitRepo,
+					"--additional-source-url", "/foo/bar",
+					"--metadata-file", "doesnotmatter15",
+					"--ignore-validation-errors",
+					"--fail-on-warning", common.InvalidSourceURLWarning,
+				}, 1)
+
+				errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
+				Expect(errorOutput).To(SatisfyAll(
+					ContainSubstring("failing on warning(s)"),
+					ContainSubstring("/foo/bar")))
+			})
 		})
 	})
 })
@@ -156,6 +156,22 @@ var _ = Describe("deplab inspect", func() {
 						ContainSubstring("metadata elements already present on image"),
 					))
 			})
+
+			It("exits with an error when the label conflicts with the image and --merge-precedence is fail", func() {
+				imagePath := CreateTinyImageWithDeplabLabel("io.deplab.metadata", metadata.Metadata{
+					Base: metadata.Base{"some-key": "some-value"},
+				})
+
+				defer os.Remove(imagePath)
+
+				_, stdErr := runDepLab([]string{
+					"inspect",
+					"--image-tar", imagePath,
+					"--merge-precedence", string(metadata.FailOnConflict),
+				}, 1)
+
+				Expect(string(getContentsOfReader(stdErr))).To(ContainSubstring("original and current metadata differ in: base"))
+			})
 		})
 		Context("has io.pivotal.metadata", func() {
 			It("merge metadata according to the rules and returns a warning", func() {
@@ -38,6 +38,23 @@ var _ = Describe("deplab", func() {
 			})
 		})
 	})
+
+	Describe("labeling", func() {
+		Context("with an image with kpack metadata", func() {
+			It("records the kpack source next to the --git source", func() {
+				md := runDeplabAgainstTar(getTestAssetPath("image-archives/kpack-image.tgz"))
+
+				var commits []interface{}
+				for _, dependency := range md.Dependencies {
+					if dependency.Source.Type == metadata.GitSourceType {
+						commits = append(commits, dependency.Source.Version["commit"])
+					}
+				}
+				Expect(commits).To(ContainElement("1736b5e3b43a8cf40b3640821ee0e26049e1a58c"))
+				Expect(commits).To(HaveLen(2))
+			})
+		})
+	})
 })
 
 func filterKpackDependencies(dependencies []metadata.Dependency) (metadata.Dependency, bool) {
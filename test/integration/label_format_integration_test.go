@@ -0,0 +1,103 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package integration_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/referrer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("deplab label format", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "deplab-integration-label-format")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	DescribeTable("stores the metadata so that inspect reads it back", func(extraArgs ...string) {
+		outputTarPath := filepath.Join(tempDir, "image.tar")
+		metadataFilePath := filepath.Join(tempDir, "metadata.json")
+
+		args := append([]string{
+			"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+			"--git", pathToGitRepo,
+			"--output-tar", outputTarPath,
+			"--metadata-file", metadataFilePath,
+		}, extraArgs...)
+		_, _ = runDepLab(args, 0)
+
+		image, err := crane.Load(outputTarPath)
+		Expect(err).ToNot(HaveOccurred())
+		config, err := image.ConfigFile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Config.Labels).To(HaveKeyWithValue(metadata.LabelEncodingLabel, extraArgs[1]))
+
+		metadataFileContent, err := ioutil.ReadFile(metadataFilePath)
+		Expect(err).ToNot(HaveOccurred())
+		var expected metadata.Metadata
+		Expect(json.Unmarshal(metadataFileContent, &expected)).To(Succeed())
+
+		stdOut, _ := runDepLab([]string{"inspect", "--image-tar", outputTarPath}, 0)
+
+		inspected := metadata.Metadata{}
+		Expect(json.NewDecoder(stdOut).Decode(&inspected)).To(Succeed())
+		Expect(inspected.Dependencies).To(Equal(expected.Dependencies))
+	},
+		Entry("gzip", "--label-format", metadata.GzipLabelFormat),
+		Entry("split", "--label-format", metadata.SplitLabelFormat, "--label-part-size", "64"),
+	)
+
+	It("stores a reference to the sbom referrer", func() {
+		outputTarPath := filepath.Join(tempDir, "image.tar")
+		target := referrer.LayoutPrefix + filepath.Join(tempDir, "layout")
+
+		_, _ = runDepLab([]string{
+			"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+			"--git", pathToGitRepo,
+			"--output-tar", outputTarPath,
+			"--sbom-referrer", target,
+			"--label-format", metadata.ReferenceLabelFormat,
+		}, 0)
+
+		image, err := crane.Load(outputTarPath)
+		Expect(err).ToNot(HaveOccurred())
+		config, err := image.ConfigFile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Config.Labels).ToNot(HaveKey(metadata.Label))
+		Expect(config.Config.Labels).To(HaveKeyWithValue(metadata.LabelReferenceLabel, HavePrefix(target+"@sha256:")))
+
+		stdOut, _ := runDepLab([]string{"inspect", "--image-tar", outputTarPath}, 0)
+
+		inspected := metadata.Metadata{}
+		Expect(json.NewDecoder(stdOut).Decode(&inspected)).To(Succeed())
+		Expect(selectGitDependencies(inspected.Dependencies)).To(HaveLen(1))
+	})
+
+	It("exits with an error for the reference format without an sbom referrer", func() {
+		_, stdErr := runDepLab([]string{
+			"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+			"--git", pathToGitRepo,
+			"--output-tar", filepath.Join(tempDir, "image.tar"),
+			"--label-format", metadata.ReferenceLabelFormat,
+		}, 1)
+
+		Expect(string(getContentsOfReader(stdErr))).To(ContainSubstring("--label-format reference requires --sbom-referrer"))
+	})
+})
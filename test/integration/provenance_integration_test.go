@@ -0,0 +1,65 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package integration_test
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/provenance"
+	"github.com/vmware-tanzu/dependency-labeler/test/test_utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("deplab provenance", func() {
+	Context("without --extended-provenance", func() {
+		It("only records the name, version and url of deplab", func() {
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"))
+
+			Expect(metadataLabel.Provenance).To(HaveLen(1))
+			Expect(metadataLabel.Provenance[0].Build).To(BeNil())
+		})
+	})
+
+	Context("with --extended-provenance", func() {
+		It("records how the metadata was produced", func() {
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"), "--extended-provenance")
+
+			Expect(metadataLabel.Provenance).To(HaveLen(1))
+			build := metadataLabel.Provenance[0].Build
+			Expect(build).ToNot(BeNil())
+
+			Expect(build.StartedOn).ToNot(BeEmpty())
+			Expect(build.InputImage.Reference).To(Equal(getTestAssetPath("image-archives/tiny.tgz")))
+			Expect(build.InputImage.Digest).To(HavePrefix("sha256:"))
+			Expect(build.Flags).To(HaveKeyWithValue("git", []string{pathToGitRepo}))
+			Expect(build.Providers).ToNot(BeEmpty())
+			Expect(build.HostArchitecture).ToNot(BeEmpty())
+		})
+	})
+
+	Context("with --provenance-file", func() {
+		It("writes an in-toto provenance statement", func() {
+			provenanceFilePath := test_utils.ExistingFileName()
+			defer test_utils.CleanupFile(provenanceFilePath)
+
+			_ = runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"), "--provenance-file", provenanceFilePath, "--tag", "example.com/labeled:tag")
+
+			provenanceFile, err := os.Open(provenanceFilePath)
+			Expect(err).ToNot(HaveOccurred())
+			defer provenanceFile.Close()
+
+			var statement provenance.Statement
+			Expect(json.NewDecoder(provenanceFile).Decode(&statement)).To(Succeed())
+
+			Expect(statement.Type).To(Equal(provenance.StatementType))
+			Expect(statement.Subject).To(HaveLen(1))
+			Expect(statement.Subject[0].Name).To(Equal("example.com/labeled:tag"))
+			Expect(statement.Subject[0].Digest).To(HaveKey("sha256"))
+			Expect(statement.Predicate.Materials[0].URI).To(Equal(getTestAssetPath("image-archives/tiny.tgz")))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package integration_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/test/test_utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("deplab providers", func() {
+	Context("when --skip-providers is set", func() {
+		It("does not run the skipped providers", func() {
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"), "--skip-providers", "dpkg,os-release")
+
+			_, ok := test_utils.SelectDpkgDependency(metadataLabel.Dependencies)
+			Expect(ok).To(BeFalse())
+			Expect(metadataLabel.Base).To(BeEmpty())
+			Expect(selectGitDependencies(metadataLabel.Dependencies)).To(HaveLen(1))
+		})
+	})
+
+	Context("when --providers is set", func() {
+		It("only runs the selected providers", func() {
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"), "--providers", "dpkg")
+
+			_, ok := test_utils.SelectDpkgDependency(metadataLabel.Dependencies)
+			Expect(ok).To(BeTrue())
+			Expect(selectGitDependencies(metadataLabel.Dependencies)).To(BeEmpty())
+			Expect(metadataLabel.Provenance).To(BeEmpty())
+		})
+
+		It("exits with an error if a provider is unknown", func() {
+			_, stdErr := runDepLab([]string{
+				"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+				"--git", pathToGitRepo,
+				"--metadata-file", "doesnotmatter",
+				"--providers", "dpkg,apk",
+			}, 1)
+
+			errorOutput := strings.TrimSpace(string(getContentsOfReader(stdErr)))
+			Expect(errorOutput).To(ContainSubstring("unknown provider(s) apk"))
+		})
+	})
+
+	Context("when --config is set", func() {
+		It("runs the providers selected in the config file", func() {
+			configFile, err := ioutil.TempFile("", "deplab-config")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(configFile.Name())
+
+			_, err = configFile.WriteString("skip_providers:\n- git\n")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configFile.Close()).To(Succeed())
+
+			metadataLabel := runDeplabAgainstTar(getTestAssetPath("image-archives/tiny.tgz"), "--config", configFile.Name())
+
+			Expect(selectGitDependencies(metadataLabel.Dependencies)).To(BeEmpty())
+			_, ok := test_utils.SelectDpkgDependency(metadataLabel.Dependencies)
+			Expect(ok).To(BeTrue())
+		})
+	})
+})
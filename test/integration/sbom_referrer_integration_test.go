@@ -0,0 +1,95 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package integration_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/metadata"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/referrer"
+	"github.com/vmware-tanzu/dependency-labeler/pkg/sbom"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("deplab sbom referrer", func() {
+	var (
+		layoutDir string
+		target    string
+	)
+
+	BeforeEach(func() {
+		var err error
+		layoutDir, err = ioutil.TempDir("", "deplab-integration-referrer")
+		Expect(err).ToNot(HaveOccurred())
+		target = referrer.LayoutPrefix + filepath.Join(layoutDir, "layout")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(layoutDir)
+	})
+
+	It("attaches the sbom to the input image without modifying it", func() {
+		inputTarPath := getTestAssetPath("image-archives/tiny.tgz")
+
+		_, _ = runDepLab([]string{
+			"--image-tar", inputTarPath,
+			"--git", pathToGitRepo,
+			"--sbom-referrer", target,
+			"--sbom-format", sbom.SPDXFormat,
+		}, 0)
+
+		inputImage, err := crane.Load(inputTarPath)
+		Expect(err).ToNot(HaveOccurred())
+		inputDigest, err := inputImage.Digest()
+		Expect(err).ToNot(HaveOccurred())
+
+		parsedTarget, err := referrer.ParseTarget(target)
+		Expect(err).ToNot(HaveOccurred())
+
+		referrers, err := parsedTarget.Discover(inputDigest, sbom.SPDXMediaType)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(referrers).To(HaveLen(1))
+
+		var document sbom.SPDXDocument
+		Expect(json.Unmarshal(referrers[0].Content, &document)).To(Succeed())
+		Expect(document.SPDXVersion).To(Equal("SPDX-2.3"))
+	})
+
+	It("prints the sbom when inspecting through referrers", func() {
+		inputTarPath := getTestAssetPath("image-archives/tiny.tgz")
+
+		_, _ = runDepLab([]string{
+			"--image-tar", inputTarPath,
+			"--git", pathToGitRepo,
+			"--sbom-referrer", target,
+		}, 0)
+
+		stdOut, _ := runDepLab([]string{
+			"inspect",
+			"--image-tar", inputTarPath,
+			"--sbom-referrer", target,
+		}, 0)
+
+		metadataLabel := metadata.Metadata{}
+		Expect(json.NewDecoder(stdOut).Decode(&metadataLabel)).To(Succeed())
+		Expect(selectGitDependencies(metadataLabel.Dependencies)).To(HaveLen(1))
+	})
+
+	It("exits with an error for an unsupported sbom format", func() {
+		_, stdErr := runDepLab([]string{
+			"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+			"--git", pathToGitRepo,
+			"--sbom-referrer", target,
+			"--sbom-format", "unknown",
+		}, 1)
+
+		Expect(string(getContentsOfReader(stdErr))).To(ContainSubstring("unsupported --sbom-format unknown"))
+	})
+})
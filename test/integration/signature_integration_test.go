@@ -0,0 +1,137 @@
+// Copyright (c) 2019-2020 VMware, Inc. All Rights Reserved.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package integration_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmware-tanzu/dependency-labeler/pkg/referrer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("deplab signature", func() {
+	var (
+		dir, privateKeyPath, publicKeyPath, outputTarPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "deplab-integration-signature")
+		Expect(err).ToNot(HaveOccurred())
+
+		privateKeyPath, publicKeyPath = writeECDSAKeys(dir, "signer")
+		outputTarPath = filepath.Join(dir, "labeled.tar")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("signs the labeled image and verifies the signature file", func() {
+		signatureFilePath := filepath.Join(dir, "signature.json")
+
+		_, _ = runDepLab([]string{
+			"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+			"--git", pathToGitRepo,
+			"--output-tar", outputTarPath,
+			"--sign-key", privateKeyPath,
+			"--signer", "ci@example.com",
+			"--signature-file", signatureFilePath,
+		}, 0)
+
+		stdOut, _ := runDepLab([]string{
+			"verify-signature",
+			"--image-tar", outputTarPath,
+			"--public-key", publicKeyPath,
+			"--signature-file", signatureFilePath,
+		}, 0)
+
+		Expect(string(getContentsOfReader(stdOut))).To(SatisfyAll(
+			ContainSubstring("verified signature by ci@example.com"),
+			ContainSubstring("of image sha256:"),
+		))
+	})
+
+	It("signs the metadata and verifies the signature referrer", func() {
+		target := referrer.LayoutPrefix + filepath.Join(dir, "layout")
+
+		_, _ = runDepLab([]string{
+			"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+			"--git", pathToGitRepo,
+			"--output-tar", outputTarPath,
+			"--sign-key", privateKeyPath,
+			"--sign-target", "metadata",
+			"--signature-referrer", target,
+		}, 0)
+
+		stdOut, _ := runDepLab([]string{
+			"verify-signature",
+			"--image-tar", outputTarPath,
+			"--public-key", publicKeyPath,
+			"--signature-referrer", target,
+		}, 0)
+
+		Expect(string(getContentsOfReader(stdOut))).To(ContainSubstring("of metadata sha256:"))
+	})
+
+	It("fails to verify against another public key", func() {
+		signatureFilePath := filepath.Join(dir, "signature.json")
+		_, otherPublicKeyPath := writeECDSAKeys(dir, "other")
+
+		_, _ = runDepLab([]string{
+			"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+			"--git", pathToGitRepo,
+			"--output-tar", outputTarPath,
+			"--sign-key", privateKeyPath,
+			"--signature-file", signatureFilePath,
+		}, 0)
+
+		_, stdErr := runDepLab([]string{
+			"verify-signature",
+			"--image-tar", outputTarPath,
+			"--public-key", otherPublicKeyPath,
+			"--signature-file", signatureFilePath,
+		}, 1)
+
+		Expect(strings.TrimSpace(string(getContentsOfReader(stdErr)))).To(ContainSubstring("no valid signature found"))
+	})
+
+	It("exits with an error if --sign-key is set without a signature output", func() {
+		_, stdErr := runDepLab([]string{
+			"--image-tar", getTestAssetPath("image-archives/tiny.tgz"),
+			"--git", pathToGitRepo,
+			"--output-tar", outputTarPath,
+			"--sign-key", privateKeyPath,
+		}, 1)
+
+		Expect(string(getContentsOfReader(stdErr))).To(ContainSubstring("ERROR: --sign-key requires one of --signature-file or --signature-referrer"))
+	})
+})
+
+func writeECDSAKeys(dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	privateDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	publicDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	Expect(err).ToNot(HaveOccurred())
+
+	privateKeyPath := filepath.Join(dir, name+".pem")
+	publicKeyPath := filepath.Join(dir, name+".pub")
+	Expect(ioutil.WriteFile(privateKeyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDER}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644)).To(Succeed())
+
+	return privateKeyPath, publicKeyPath
+}
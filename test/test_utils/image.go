@@ -25,6 +25,21 @@ func NewMockImageWithEmptyConfig() MockImage {
 	}
 }
 
+func NewMockImageWithLabels(labels map[string]string) MockImage {
+	config := &v1.ConfigFile{}
+	config.Config.Labels = labels
+
+	return MockImage{
+		config: config,
+	}
+}
+
+func NewMockImageWithConfig(config *v1.ConfigFile) MockImage {
+	return MockImage{
+		config: config,
+	}
+}
+
 func (m MockImage) Cleanup() {
 	panic("implement me")
 }